@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api-cli/input"
+	"code.cloudfoundry.org/routing-api/models"
+	"github.com/urfave/cli"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+var planFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "file, f",
+		Usage: "Read the desired routes from this file, directory or pattern, or from stdin for -, can be repeated (required)",
+	},
+	inputFormatFlag,
+	cli.BoolFlag{
+		Name:  "prune",
+		Usage: "Delete the registered routes in scope that are not desired (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "scope-log-guid",
+		Usage: "Manage the HTTP routes with this log guid, can be repeated (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "scope-route",
+		Usage: "Manage the HTTP routes and SNI hostnames matching this glob, can be repeated (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "scope-router-group",
+		Usage: "Manage the TCP routes of this router group name or guid, can be repeated (optional)",
+	},
+	noValidateFlag,
+	cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "Do not color the changes (optional)",
+	},
+}
+
+// planningFlagNames are the names of the planFlags that only apply when
+// planning, and are meaningless for a saved plan.
+var planningFlagNames = []string{"input-format", "prune", "scope-log-guid", "scope-route", "scope-router-group", "no-validate"}
+
+var planCommand = cli.Command{
+	Name:  "plan",
+	Usage: "Shows the changes that make the registered routes match the desired ones",
+	Description: `The desired routes are read from JSON or YAML files holding lists of
+HTTP routes and TCP route mappings, like so:
+routes:
+- {route: foo.com, port: 12345, ip: 1.2.3.4, ttl: 60, log_guid: my-app}
+tcp_routes:
+- {router_group_guid: guid, port: 5200, backend_ip: 1.2.3.4, backend_port: 60000, ttl: 60}`,
+	Action: planRoutes,
+	Flags: append(append(flags, planFlags...), cli.StringFlag{
+		Name:  "out",
+		Usage: "Save the plan to this file, to apply exactly these changes with apply <file> (optional)",
+	}),
+}
+
+var applyCommand = cli.Command{
+	Name:      "apply",
+	Usage:     "Registers and, with --prune, unregisters routes to match the desired ones",
+	ArgsUsage: "[plan file]",
+	Description: planCommand.Description + `
+
+Given a plan file saved with plan --out, apply makes exactly the changes of
+that plan, and refuses to if the routes changed since it was made.`,
+	Action: applyRoutes,
+	Flags:  append(flags, planFlags...),
+}
+
+func planRoutes(c *cli.Context) {
+	errorMessage := "planning routes failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "plan")...)
+	issues = append(issues, checkInputFormat(c)...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "plan")
+	}
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	plan, err := makePlan(c, client)
+	checkError(errorMessage, err)
+
+	printPlan(c, plan)
+
+	if out := c.String("out"); out != "" {
+		conn, err := resolveConnection(c)
+		checkError(errorMessage, err)
+
+		err = savePlan(out, commands.SavedPlan{API: conn.api, CreatedAt: time.Now(), Plan: plan})
+		checkError(errorMessage, err)
+		fmt.Printf("Saved the plan to %s, apply it with: rtr apply %s\n", out, out)
+	}
+}
+
+func applyRoutes(c *cli.Context) {
+	errorMessage := "applying routes failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "apply")...)
+	issues = append(issues, checkInputFormat(c)...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "apply")
+	}
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	var plan commands.Plan
+	if c.NArg() > 0 {
+		plan, err = loadPlan(c, client, c.Args().First())
+	} else {
+		plan, err = makePlan(c, client)
+	}
+	checkError(errorMessage, err)
+
+	printPlan(c, plan)
+
+	err = commands.Apply(client, plan)
+	checkError(errorMessage, err)
+
+	fmt.Println("Successfully applied the changes")
+}
+
+// makePlan reads and validates the desired routes, and compares them with
+// the registered ones in the scope given with the flags.
+func makePlan(c *cli.Context, client routing_api.Client) (commands.Plan, error) {
+	state, err := input.ReadState(c.StringSlice("file"), c.String("input-format"), os.Stdin)
+	if err != nil {
+		return commands.Plan{}, err
+	}
+
+	if !c.Bool("no-validate") {
+		err = commands.ValidateRegister(state.Routes)
+		if err != nil {
+			return commands.Plan{}, err
+		}
+	}
+
+	scope := commands.Scope{LogGuids: c.StringSlice("scope-log-guid")}
+	for _, route := range c.StringSlice("scope-route") {
+		scope.Routes = append(scope.Routes, commands.Glob(route))
+	}
+	scope.RouterGroupGuids, err = routerGroupGuids(client, c.StringSlice("scope-router-group"))
+	if err != nil {
+		return commands.Plan{}, err
+	}
+
+	return commands.MakePlan(client, state.Routes, state.TcpRoutes, scope, c.Bool("prune"))
+}
+
+func savePlan(name string, plan commands.SavedPlan) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	err = commands.WritePlan(file, plan)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// loadPlan reads a plan saved with plan --out, and checks that it was made
+// for the same routing-api and that its routes did not change since.
+func loadPlan(c *cli.Context, client routing_api.Client, name string) (commands.Plan, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return commands.Plan{}, err
+	}
+	defer file.Close()
+
+	saved, err := commands.ReadPlan(file)
+	if err != nil {
+		return commands.Plan{}, fmt.Errorf("%s: %s", name, err)
+	}
+
+	conn, err := resolveConnection(c)
+	if err != nil {
+		return commands.Plan{}, err
+	}
+	if saved.API != conn.api {
+		return commands.Plan{}, fmt.Errorf("%s was planned for %s, not %s", name, saved.API, conn.api)
+	}
+
+	err = commands.CheckDrift(client, saved.Plan)
+	if err != nil {
+		return commands.Plan{}, fmt.Errorf("%s is stale, plan again: %s", name, err)
+	}
+	return saved.Plan, nil
+}
+
+// printPlan prints one line per change, colored unless --no-color is given
+// or stdout is not a terminal, followed by how many routes change.
+func printPlan(c *cli.Context, plan commands.Plan) {
+	color := !c.Bool("no-color") && isTerminal(os.Stdout)
+
+	for _, change := range plan.Routes {
+		printChange(color, change.Action, "route "+describeRoute(change.Route), routeUpdates(change))
+	}
+	for _, change := range plan.TcpRoutes {
+		printChange(color, change.Action, "tcp route "+describeTcpRoute(change.Mapping), tcpRouteUpdates(change))
+	}
+
+	counts := plan.Counts()
+	fmt.Printf("Plan: %d to create, %d to update, %d to delete, %d unchanged\n",
+		counts[commands.ChangeCreate], counts[commands.ChangeUpdate], counts[commands.ChangeDelete], counts[commands.ChangeUnchanged])
+}
+
+func printChange(color bool, action, description string, updates []string) {
+	var symbol, code string
+	switch action {
+	case commands.ChangeCreate:
+		symbol, code = "+", colorGreen
+	case commands.ChangeUpdate:
+		symbol, code = "~", colorYellow
+	case commands.ChangeDelete:
+		symbol, code = "-", colorRed
+	default:
+		return
+	}
+
+	line := symbol + " " + description
+	if len(updates) > 0 {
+		line += " (" + strings.Join(updates, ", ") + ")"
+	}
+	if color {
+		line = code + line + colorReset
+	}
+	fmt.Println(line)
+}
+
+func describeRoute(route models.Route) string {
+	return fmt.Sprintf("%s -> %s:%d", route.Route, route.IP, route.Port)
+}
+
+func describeTcpRoute(mapping models.TcpRouteMapping) string {
+	description := fmt.Sprintf("%s:%d -> %s:%d", mapping.RouterGroupGuid, mapping.ExternalPort, mapping.HostIP, mapping.HostPort)
+	if mapping.SniHostname != nil {
+		description += " sni " + *mapping.SniHostname
+	}
+	return description
+}
+
+func routeUpdates(change commands.RouteChange) []string {
+	if change.Action != commands.ChangeUpdate {
+		return nil
+	}
+
+	var updates []string
+	if update := ttlUpdate(change.Current.TTL, change.Route.TTL); update != "" {
+		updates = append(updates, update)
+	}
+	if change.Current.RouteServiceUrl != change.Route.RouteServiceUrl {
+		updates = append(updates, fmt.Sprintf("route_service_url %q -> %q", change.Current.RouteServiceUrl, change.Route.RouteServiceUrl))
+	}
+	return updates
+}
+
+func tcpRouteUpdates(change commands.TcpRouteChange) []string {
+	if change.Action != commands.ChangeUpdate {
+		return nil
+	}
+
+	var updates []string
+	if update := ttlUpdate(change.Current.TTL, change.Mapping.TTL); update != "" {
+		updates = append(updates, update)
+	}
+	if change.Current.IsolationSegment != change.Mapping.IsolationSegment {
+		updates = append(updates, fmt.Sprintf("isolation_segment %q -> %q", change.Current.IsolationSegment, change.Mapping.IsolationSegment))
+	}
+	return updates
+}
+
+func ttlUpdate(current, desired *int) string {
+	from, to := "none", "none"
+	if current != nil {
+		from = fmt.Sprint(*current)
+	}
+	if desired != nil {
+		to = fmt.Sprint(*desired)
+	}
+	if from == to {
+		return ""
+	}
+	return "ttl " + from + " -> " + to
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// ArchiveVersion is the version of the archives written by WriteArchive.
+const ArchiveVersion = 1
+
+// The parts of an archive that can be imported on their own.
+const (
+	PartHttp         = "http"
+	PartTcp          = "tcp"
+	PartRouterGroups = "router-groups"
+)
+
+var ArchiveParts = []string{PartHttp, PartTcp, PartRouterGroups}
+
+// Archive is a snapshot of everything the routing-api holds.
+type Archive struct {
+	Version      int                      `json:"version"`
+	Metadata     ArchiveMetadata          `json:"metadata"`
+	RouterGroups []models.RouterGroup     `json:"router_groups"`
+	Routes       []models.Route           `json:"routes"`
+	TcpRoutes    []models.TcpRouteMapping `json:"tcp_routes"`
+}
+
+// ArchiveMetadata tells where and when an archive was exported.
+type ArchiveMetadata struct {
+	Target     string        `json:"target,omitempty"`
+	API        string        `json:"api"`
+	ExportedAt time.Time     `json:"exported_at"`
+	Counts     ArchiveCounts `json:"counts"`
+}
+
+// ArchiveCounts counts the entries of an archive.
+type ArchiveCounts struct {
+	RouterGroups int `json:"router_groups"`
+	Routes       int `json:"routes"`
+	TcpRoutes    int `json:"tcp_routes"`
+}
+
+// Export lists the router groups, HTTP routes and TCP route mappings, and
+// fills in the counts of the metadata.
+func Export(client routing_api.Client, metadata ArchiveMetadata) (Archive, error) {
+	archive := Archive{Version: ArchiveVersion, Metadata: metadata}
+
+	var err error
+	archive.RouterGroups, err = ListRouterGroups(client)
+	if err != nil {
+		return archive, err
+	}
+	archive.Routes, err = List(client)
+	if err != nil {
+		return archive, err
+	}
+	archive.TcpRoutes, err = ListTcp(client, nil)
+	if err != nil {
+		return archive, err
+	}
+
+	archive.Metadata.Counts = ArchiveCounts{
+		RouterGroups: len(archive.RouterGroups),
+		Routes:       len(archive.Routes),
+		TcpRoutes:    len(archive.TcpRoutes),
+	}
+	return archive, nil
+}
+
+// WriteArchive writes the archive as gzipped JSON.
+func WriteArchive(w io.Writer, archive Archive) error {
+	gz := gzip.NewWriter(w)
+	err := json.NewEncoder(gz).Encode(archive)
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadArchive reads an archive written by WriteArchive, and checks that it
+// holds as many entries as its metadata counts.
+func ReadArchive(r io.Reader) (Archive, error) {
+	var archive Archive
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return archive, fmt.Errorf("not an archive: %s", err)
+	}
+	defer gz.Close()
+
+	err = json.NewDecoder(gz).Decode(&archive)
+	if err != nil {
+		return archive, fmt.Errorf("not an archive: %s", err)
+	}
+	if archive.Version != ArchiveVersion {
+		return archive, fmt.Errorf("unsupported archive version %d", archive.Version)
+	}
+
+	counts := ArchiveCounts{
+		RouterGroups: len(archive.RouterGroups),
+		Routes:       len(archive.Routes),
+		TcpRoutes:    len(archive.TcpRoutes),
+	}
+	if counts != archive.Metadata.Counts {
+		return archive, fmt.Errorf("archive is incomplete: holds %d router groups, %d routes and %d tcp routes, expected %d, %d and %d",
+			counts.RouterGroups, counts.Routes, counts.TcpRoutes,
+			archive.Metadata.Counts.RouterGroups, archive.Metadata.Counts.Routes, archive.Metadata.Counts.TcpRoutes)
+	}
+	return archive, nil
+}
+
+// ImportSummary counts what Import restored.
+type ImportSummary struct {
+	RouterGroupsCreated int
+	RouterGroupsUpdated int
+	Routes              int
+	TcpRoutes           int
+}
+
+// Import restores the given parts of the archive, all of them when none are
+// given. Router groups are matched by name, so that they are created when
+// missing and their reservable ports are updated when they differ. TCP route
+// mappings are moved to the router group of the same name, which may have
+// another guid than the exported one. A positive ttlOverride replaces the
+// TTL of every route, which would otherwise expire as soon as its exported
+// TTL passes.
+func Import(client routing_api.Client, archive Archive, parts []string, ttlOverride int) (ImportSummary, error) {
+	var summary ImportSummary
+	included := func(part string) bool {
+		return len(parts) == 0 || containsString(parts, part)
+	}
+
+	if included(PartRouterGroups) {
+		err := importRouterGroups(client, archive.RouterGroups, &summary)
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	if included(PartHttp) && len(archive.Routes) > 0 {
+		routes := make([]models.Route, 0, len(archive.Routes))
+		for _, route := range archive.Routes {
+			route.ModificationTag = models.ModificationTag{}
+			if ttlOverride > 0 {
+				ttl := ttlOverride
+				route.TTL = &ttl
+			}
+			routes = append(routes, route)
+		}
+
+		err := Register(client, routes)
+		if err != nil {
+			return summary, err
+		}
+		summary.Routes = len(routes)
+	}
+
+	if included(PartTcp) && len(archive.TcpRoutes) > 0 {
+		mappings, err := remapRouterGroups(client, archive.RouterGroups, archive.TcpRoutes)
+		if err != nil {
+			return summary, err
+		}
+		for i := range mappings {
+			mappings[i].ModificationTag = models.ModificationTag{}
+			if ttlOverride > 0 {
+				ttl := ttlOverride
+				mappings[i].TTL = &ttl
+			}
+		}
+
+		err = RegisterTcp(client, mappings)
+		if err != nil {
+			return summary, err
+		}
+		summary.TcpRoutes = len(mappings)
+	}
+
+	return summary, nil
+}
+
+func importRouterGroups(client routing_api.Client, groups []models.RouterGroup, summary *ImportSummary) error {
+	existing, err := ListRouterGroups(client)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]models.RouterGroup, len(existing))
+	for _, group := range existing {
+		byName[group.Name] = group
+	}
+
+	for _, group := range groups {
+		current, found := byName[group.Name]
+		switch {
+		case !found:
+			group.Guid = ""
+			if err := CreateRouterGroup(client, group); err != nil {
+				return fmt.Errorf("router group %s: %s", group.Name, err)
+			}
+			summary.RouterGroupsCreated++
+		case current.ReservablePorts != group.ReservablePorts:
+			group.Guid = current.Guid
+			if err := UpdateRouterGroup(client, group); err != nil {
+				return fmt.Errorf("router group %s: %s", group.Name, err)
+			}
+			summary.RouterGroupsUpdated++
+		}
+	}
+	return nil
+}
+
+// remapRouterGroups replaces the exported router group guids of the mappings
+// with the guids of the registered router groups of the same name.
+func remapRouterGroups(client routing_api.Client, exported []models.RouterGroup, mappings []models.TcpRouteMapping) ([]models.TcpRouteMapping, error) {
+	registered, err := ListRouterGroups(client)
+	if err != nil {
+		return nil, err
+	}
+	guids := make(map[string]string, len(registered))
+	for _, group := range registered {
+		guids[group.Name] = group.Guid
+	}
+	names := make(map[string]string, len(exported))
+	for _, group := range exported {
+		names[group.Guid] = group.Name
+	}
+
+	remapped := make([]models.TcpRouteMapping, 0, len(mappings))
+	for _, mapping := range mappings {
+		name, found := names[mapping.RouterGroupGuid]
+		if !found {
+			return nil, fmt.Errorf("tcp route %s: unknown router group %s", tcpRouteKey(mapping), mapping.RouterGroupGuid)
+		}
+		guid, found := guids[name]
+		if !found {
+			return nil, fmt.Errorf("tcp route %s: router group %s does not exist", tcpRouteKey(mapping), name)
+		}
+		mapping.RouterGroupGuid = guid
+		remapped = append(remapped, mapping)
+	}
+	return remapped, nil
+}
@@ -0,0 +1,139 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Archive", func() {
+	var (
+		client  *fake_routing_api.FakeClient
+		archive commands.Archive
+	)
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+		client.RouterGroupsReturns([]models.RouterGroup{
+			{Guid: "old-guid", Name: "default-tcp", Type: models.RouterGroup_TCP, ReservablePorts: "1024-1033"},
+		}, nil)
+		route := models.NewRoute("a.example.com", 8080, "10.0.0.1", "team-a", "", 60)
+		route.ModificationTag = models.ModificationTag{Guid: "tag", Index: 7}
+		client.RoutesReturns([]models.Route{route}, nil)
+		client.TcpRouteMappingsReturns([]models.TcpRouteMapping{
+			models.NewTcpRouteMapping("old-guid", 1024, "10.0.0.1", 61000, 0, "", nil, 60, models.ModificationTag{}),
+		}, nil)
+
+		var err error
+		archive, err = commands.Export(client, commands.ArchiveMetadata{
+			Target:     "prod",
+			API:        "https://api.example.com",
+			ExportedAt: time.Unix(1700000000, 0).UTC(),
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe(".Export", func() {
+		It("exports everything with its counts", func() {
+			Expect(archive.Version).To(Equal(commands.ArchiveVersion))
+			Expect(archive.Metadata.Target).To(Equal("prod"))
+			Expect(archive.Metadata.Counts).To(Equal(commands.ArchiveCounts{RouterGroups: 1, Routes: 1, TcpRoutes: 1}))
+			Expect(archive.Routes[0].Route).To(Equal("a.example.com"))
+			Expect(archive.TcpRoutes[0].RouterGroupGuid).To(Equal("old-guid"))
+		})
+	})
+
+	Describe(".ReadArchive", func() {
+		It("reads the archive it wrote", func() {
+			var buf bytes.Buffer
+			Expect(commands.WriteArchive(&buf, archive)).To(Succeed())
+
+			read, err := commands.ReadArchive(&buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read.Metadata).To(Equal(archive.Metadata))
+			Expect(read.RouterGroups).To(Equal(archive.RouterGroups))
+			Expect(read.Routes).To(HaveLen(1))
+			Expect(read.TcpRoutes).To(HaveLen(1))
+		})
+
+		It("rejects archives missing entries", func() {
+			archive.Routes = nil
+
+			var buf bytes.Buffer
+			Expect(commands.WriteArchive(&buf, archive)).To(Succeed())
+
+			_, err := commands.ReadArchive(&buf)
+			Expect(err).To(MatchError("archive is incomplete: holds 1 router groups, 0 routes and 1 tcp routes, expected 1, 1 and 1"))
+		})
+	})
+
+	Describe(".Import", func() {
+		BeforeEach(func() {
+			client.RouterGroupsReturns([]models.RouterGroup{
+				{Guid: "new-guid", Name: "default-tcp", Type: models.RouterGroup_TCP, ReservablePorts: "1024-1033"},
+			}, nil)
+		})
+
+		It("restores the routes on the router group of the same name", func() {
+			summary, err := commands.Import(client, archive, nil, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(summary).To(Equal(commands.ImportSummary{Routes: 1, TcpRoutes: 1}))
+
+			Expect(client.CreateRouterGroupCallCount()).To(Equal(0))
+			Expect(client.UpdateRouterGroupCallCount()).To(Equal(0))
+
+			routes := client.UpsertRoutesArgsForCall(0)
+			Expect(routes[0].ModificationTag).To(Equal(models.ModificationTag{}))
+			Expect(*routes[0].TTL).To(Equal(60))
+
+			mappings := client.UpsertTcpRouteMappingsArgsForCall(0)
+			Expect(mappings[0].RouterGroupGuid).To(Equal("new-guid"))
+		})
+
+		It("creates missing router groups and updates changed ones", func() {
+			archive.RouterGroups = append(archive.RouterGroups, models.RouterGroup{Guid: "other-guid", Name: "other-tcp", Type: models.RouterGroup_TCP, ReservablePorts: "2000"})
+			archive.RouterGroups[0].ReservablePorts = "1024-1040"
+
+			summary, err := commands.Import(client, archive, []string{commands.PartRouterGroups}, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(summary).To(Equal(commands.ImportSummary{RouterGroupsCreated: 1, RouterGroupsUpdated: 1}))
+
+			Expect(client.UpdateRouterGroupArgsForCall(0).Guid).To(Equal("new-guid"))
+			Expect(client.CreateRouterGroupArgsForCall(0).Name).To(Equal("other-tcp"))
+			Expect(client.UpsertRoutesCallCount()).To(Equal(0))
+			Expect(client.UpsertTcpRouteMappingsCallCount()).To(Equal(0))
+		})
+
+		It("only counts the router groups it changed", func() {
+			archive.RouterGroups = append(archive.RouterGroups, models.RouterGroup{Guid: "other-guid", Name: "other-tcp", Type: models.RouterGroup_TCP, ReservablePorts: "2000"})
+			archive.RouterGroups[0].ReservablePorts = "1024-1040"
+			client.CreateRouterGroupReturns(errors.New("forbidden"))
+
+			summary, err := commands.Import(client, archive, []string{commands.PartRouterGroups}, 0)
+			Expect(err).To(MatchError("router group other-tcp: forbidden"))
+			Expect(summary).To(Equal(commands.ImportSummary{RouterGroupsUpdated: 1}))
+		})
+
+		It("overrides the TTL of the restored routes", func() {
+			_, err := commands.Import(client, archive, []string{commands.PartHttp, commands.PartTcp}, 3600)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*client.UpsertRoutesArgsForCall(0)[0].TTL).To(Equal(3600))
+			Expect(*client.UpsertTcpRouteMappingsArgsForCall(0)[0].TTL).To(Equal(3600))
+		})
+
+		It("fails when the router group of a tcp route does not exist", func() {
+			client.RouterGroupsReturns(nil, nil)
+
+			_, err := commands.Import(client, archive, []string{commands.PartTcp}, 0)
+			Expect(err).To(MatchError(ContainSubstring("router group default-tcp does not exist")))
+			Expect(client.UpsertTcpRouteMappingsCallCount()).To(Equal(0))
+		})
+	})
+})
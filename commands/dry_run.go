@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"strconv"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+const (
+	ChangeCreate    = "create"
+	ChangeUpdate    = "update"
+	ChangeUnchanged = "unchanged"
+	ChangeDelete    = "delete"
+	ChangeNoOp      = "no-op"
+)
+
+// RouteChange is what registering or unregistering Route would do. Current
+// is the registered route with the same hostname and backend, if any.
+type RouteChange struct {
+	Action  string        `json:"action"`
+	Route   models.Route  `json:"route"`
+	Current *models.Route `json:"current,omitempty"`
+}
+
+// DryRunRegister compares the routes to register with the registered ones,
+// without changing anything. Routes are created when no route with the same
+// hostname, IP and port is registered, and updated when its TTL or route
+// service differ.
+func DryRunRegister(client routing_api.Client, routes []models.Route) ([]RouteChange, error) {
+	registered, err := List(client)
+	if err != nil {
+		return nil, err
+	}
+	return planRoutes(routes, registered), nil
+}
+
+// DryRunUnregister reports which of the routes to unregister are registered,
+// without changing anything. Deleting the others is a no-op.
+func DryRunUnregister(client routing_api.Client, routes []models.Route) ([]RouteChange, error) {
+	registered, err := List(client)
+	if err != nil {
+		return nil, err
+	}
+	current := routesByKey(registered)
+
+	changes := make([]RouteChange, 0, len(routes))
+	for _, route := range routes {
+		change := RouteChange{Action: ChangeNoOp, Route: route}
+		if registered, found := current[routeKey(route)]; found {
+			change.Current = &registered
+			change.Action = ChangeDelete
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// CountChanges counts the changes by action.
+func CountChanges(changes []RouteChange) map[string]int {
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Action]++
+	}
+	return counts
+}
+
+func planRoutes(routes, registered []models.Route) []RouteChange {
+	current := routesByKey(registered)
+
+	changes := make([]RouteChange, 0, len(routes))
+	for _, route := range routes {
+		change := RouteChange{Action: ChangeCreate, Route: route}
+		if registered, found := current[routeKey(route)]; found {
+			change.Current = &registered
+			change.Action = ChangeUnchanged
+			if !sameTTL(route.TTL, registered.TTL) || route.RouteServiceUrl != registered.RouteServiceUrl {
+				change.Action = ChangeUpdate
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func routesByKey(routes []models.Route) map[string]models.Route {
+	byKey := make(map[string]models.Route, len(routes))
+	for _, route := range routes {
+		byKey[routeKey(route)] = route
+	}
+	return byKey
+}
+
+// routeKey identifies a route by its hostname and backend.
+func routeKey(route models.Route) string {
+	return route.Route + " " + route.IP + ":" + strconv.Itoa(int(route.Port))
+}
+
+func sameTTL(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,69 @@
+package commands_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DryRun", func() {
+	var (
+		client *fake_routing_api.FakeClient
+		routes []models.Route
+	)
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+		client.RoutesReturns([]models.Route{
+			models.NewRoute("a.com", 8080, "10.0.0.1", "", "", 60),
+			models.NewRoute("b.com", 8080, "10.0.0.1", "", "", 60),
+			models.NewRoute("c.com", 8080, "10.0.0.1", "", "https://rs.example.com", 60),
+		}, nil)
+
+		routes = []models.Route{
+			models.NewRoute("a.com", 8080, "10.0.0.1", "", "", 60),
+			models.NewRoute("b.com", 8080, "10.0.0.1", "", "", 120),
+			models.NewRoute("c.com", 8080, "10.0.0.1", "", "", 60),
+			models.NewRoute("a.com", 8081, "10.0.0.1", "", "", 60),
+		}
+	})
+
+	It("reports which routes would be created, updated or left unchanged", func() {
+		changes, err := commands.DryRunRegister(client, routes)
+		Expect(err).NotTo(HaveOccurred())
+
+		actions := []string{}
+		for _, change := range changes {
+			actions = append(actions, change.Action)
+		}
+		Expect(actions).To(Equal([]string{commands.ChangeUnchanged, commands.ChangeUpdate, commands.ChangeUpdate, commands.ChangeCreate}))
+		Expect(*changes[1].Current.TTL).To(Equal(60))
+		Expect(changes[3].Current).To(BeNil())
+
+		Expect(commands.CountChanges(changes)).To(Equal(map[string]int{
+			commands.ChangeUnchanged: 1,
+			commands.ChangeUpdate:    2,
+			commands.ChangeCreate:    1,
+		}))
+		Expect(client.UpsertRoutesCallCount()).To(Equal(0))
+	})
+
+	It("reports which deletes would be no-ops", func() {
+		changes, err := commands.DryRunUnregister(client, routes[2:])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(HaveLen(2))
+		Expect(changes[0].Action).To(Equal(commands.ChangeDelete))
+		Expect(changes[1].Action).To(Equal(commands.ChangeNoOp))
+		Expect(client.DeleteRoutesCallCount()).To(Equal(0))
+	})
+
+	It("returns the error listing the routes", func() {
+		client.RoutesReturns(nil, errors.New("boom"))
+		_, err := commands.DryRunRegister(client, routes)
+		Expect(err).To(MatchError("boom"))
+	})
+})
@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	routing_api "code.cloudfoundry.org/routing-api"
+)
+
+// minimumRefreshInterval keeps a TokenRefresher from fetching tokens in a
+// tight loop when the tokens it gets are already expired.
+const minimumRefreshInterval = time.Second
+
+// minimumRetryBackoff keeps an EventStream retrying forever from resubscribing
+// in a tight loop when no backoff is configured.
+const minimumRetryBackoff = 100 * time.Millisecond
+
+// maximumRetryBackoff caps how long an EventStream waits before resubscribing
+// after its subscription failed.
+const maximumRetryBackoff = time.Minute
+
+// TokenFetcher returns a new access token and the time it expires at.
+type TokenFetcher func() (token string, expiry time.Time, err error)
+
+// TokenRefresher replaces the access token of Client shortly before it
+// expires, and closes the event subscriptions of the EventStreams using it so
+// that they resubscribe with the new token.
+type TokenRefresher struct {
+	Client routing_api.Client
+	Fetch  TokenFetcher
+	// Expiry is when the token the client currently uses expires. The token
+	// is never refreshed when it is zero.
+	Expiry time.Time
+	// Buffer is how long before the expiry the token is refreshed.
+	Buffer time.Duration
+	// RetryInterval is how long to wait before fetching a token again after
+	// it failed.
+	RetryInterval time.Duration
+	// Failed is called with the errors fetching a token, if set.
+	Failed func(error)
+	Clock  clock.Clock
+
+	mu      sync.Mutex
+	sources map[io.Closer]bool
+}
+
+// Run refreshes the token until stop is closed.
+func (r *TokenRefresher) Run(stop <-chan struct{}) {
+	if r.Expiry.IsZero() {
+		return
+	}
+
+	delay := r.refreshDelay(r.Expiry)
+	for {
+		timer := r.Clock.NewTimer(delay)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		token, expiry, err := r.Fetch()
+		if err != nil {
+			if r.Failed != nil {
+				r.Failed(err)
+			}
+			delay = r.RetryInterval
+			continue
+		}
+
+		r.refresh(token)
+		if expiry.IsZero() {
+			return
+		}
+		delay = r.refreshDelay(expiry)
+	}
+}
+
+// refreshDelay is how long to wait before refreshing a token expiring at
+// expiry. Tokens living shorter than the buffer are refreshed halfway
+// through their remaining lifetime.
+func (r *TokenRefresher) refreshDelay(expiry time.Time) time.Duration {
+	lifetime := expiry.Sub(r.Clock.Now())
+	delay := lifetime - r.Buffer
+	if delay < lifetime/2 {
+		delay = lifetime / 2
+	}
+	if delay < minimumRefreshInterval {
+		delay = minimumRefreshInterval
+	}
+	return delay
+}
+
+func (r *TokenRefresher) refresh(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Client.SetToken(token)
+	for source, refreshed := range r.sources {
+		if !refreshed {
+			r.sources[source] = true
+			source.Close()
+		}
+	}
+}
+
+// WithClient runs fn while the token cannot be replaced. The client reads
+// its token without synchronization, so every use of it that may overlap a
+// refresh has to go through WithClient.
+func (r *TokenRefresher) WithClient(fn func() error) error {
+	if r == nil {
+		return fn()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fn()
+}
+
+// subscribe subscribes while the token cannot be replaced, and tracks the
+// source so that the next refresh closes it.
+func (r *TokenRefresher) subscribe(subscribe func() (io.Closer, func() error, error)) (io.Closer, func() error, error) {
+	if r == nil {
+		return subscribe()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source, next, err := subscribe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.sources == nil {
+		r.sources = map[io.Closer]bool{}
+	}
+	r.sources[source] = false
+	return source, next, nil
+}
+
+// untrack reports whether the source was closed because of a refresh.
+func (r *TokenRefresher) untrack(source io.Closer) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refreshed := r.sources[source]
+	delete(r.sources, source)
+	return refreshed
+}
+
+// EventStream subscribes to the events of the routing-api, renewing the
+// subscription whenever Refresher replaced the token, and after failures
+// when Forever is set.
+type EventStream struct {
+	Client routing_api.Client
+	// Refresher is optional, without it the token is never refreshed.
+	Refresher *TokenRefresher
+	// Resubscribed is called after the subscription was renewed with a
+	// refreshed token, if set.
+	Resubscribed func()
+	// Subscribed is called after every successful subscription, including
+	// renewals, before its first event is handled, if set. Events published
+	// while no subscription was open are missed, so callers keeping state
+	// should reload it then.
+	Subscribed func()
+	// MaxRetries is handed to the routing-api client, which then retries
+	// connecting a subscription that many times, a second apart, before it
+	// fails. These retries are not reported to Reconnecting.
+	MaxRetries uint16
+	// Forever renews failed subscriptions without a limit.
+	Forever bool
+	// Backoff is how long to wait before renewing a failed subscription when
+	// Forever is set. It doubles with every failure in a row, up to a minute.
+	Backoff time.Duration
+	// Reconnecting is called before waiting to renew a failed subscription,
+	// if set. Attempt counts the failures in a row, starting at 1.
+	Reconnecting func(attempt int, delay time.Duration, err error)
+	// Clock is only required when Forever is set.
+	Clock clock.Clock
+}
+
+// StreamHttp calls handle with every HTTP route event until the subscription
+// fails for good, and returns the error it failed with.
+func (s EventStream) StreamHttp(handle func(routing_api.Event)) error {
+	return s.stream(func() (io.Closer, func() error, error) {
+		var source routing_api.EventSource
+		var err error
+		if s.MaxRetries > 0 {
+			source, err = s.Client.SubscribeToEventsWithMaxRetries(s.MaxRetries)
+		} else {
+			source, err = s.Client.SubscribeToEvents()
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return source, func() error {
+			event, err := source.Next()
+			if err == nil {
+				handle(event)
+			}
+			return err
+		}, nil
+	})
+}
+
+// StreamTcp calls handle with every TCP route event until the subscription
+// fails for good, and returns the error it failed with.
+func (s EventStream) StreamTcp(handle func(routing_api.TcpEvent)) error {
+	return s.stream(func() (io.Closer, func() error, error) {
+		var source routing_api.TcpEventSource
+		var err error
+		if s.MaxRetries > 0 {
+			source, err = s.Client.SubscribeToTcpEventsWithMaxRetries(s.MaxRetries)
+		} else {
+			source, err = s.Client.SubscribeToTcpEvents()
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return source, func() error {
+			event, err := source.Next()
+			if err == nil {
+				handle(event)
+			}
+			return err
+		}, nil
+	})
+}
+
+func (s EventStream) stream(subscribe func() (io.Closer, func() error, error)) error {
+	failures := 0
+	for {
+		source, next, err := s.Refresher.subscribe(subscribe)
+		if err == nil {
+			if s.Subscribed != nil {
+				s.Subscribed()
+			}
+			for err == nil {
+				err = next()
+				if err == nil {
+					failures = 0
+				}
+			}
+
+			if s.Refresher.untrack(source) {
+				if s.Resubscribed != nil {
+					s.Resubscribed()
+				}
+				continue
+			}
+		}
+
+		if !s.Forever {
+			return err
+		}
+		failures++
+
+		delay := s.retryDelay(failures)
+		if s.Reconnecting != nil {
+			s.Reconnecting(failures, delay, err)
+		}
+		s.Clock.Sleep(delay)
+	}
+}
+
+// retryDelay is how long to wait before renewing a subscription after the
+// given number of failures in a row.
+func (s EventStream) retryDelay(failures int) time.Duration {
+	delay := s.Backoff
+	if delay < minimumRetryBackoff {
+		delay = minimumRetryBackoff
+	}
+	for i := 1; i < failures && delay < maximumRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maximumRetryBackoff {
+		delay = maximumRetryBackoff
+	}
+	return delay
+}
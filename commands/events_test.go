@@ -0,0 +1,348 @@
+package commands_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// blockingEventSource returns a fake event source whose Next blocks until it
+// is closed.
+func blockingEventSource() *fake_routing_api.FakeEventSource {
+	closed := make(chan struct{})
+	source := &fake_routing_api.FakeEventSource{}
+	source.NextStub = func() (routing_api.Event, error) {
+		<-closed
+		return routing_api.Event{}, errors.New("closed")
+	}
+	source.CloseStub = func() error {
+		close(closed)
+		return nil
+	}
+	return source
+}
+
+// blockingTcpEventSource is blockingEventSource for TCP events.
+func blockingTcpEventSource() *fake_routing_api.FakeTcpEventSource {
+	closed := make(chan struct{})
+	source := &fake_routing_api.FakeTcpEventSource{}
+	source.NextStub = func() (routing_api.TcpEvent, error) {
+		<-closed
+		return routing_api.TcpEvent{}, errors.New("closed")
+	}
+	source.CloseStub = func() error {
+		close(closed)
+		return nil
+	}
+	return source
+}
+
+var _ = Describe("Events", func() {
+	var (
+		client *fake_routing_api.FakeClient
+		clock  *fakeclock.FakeClock
+	)
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+		clock = fakeclock.NewFakeClock(time.Now())
+	})
+
+	Describe("EventStream", func() {
+		It("hands the events to the handler until the subscription fails", func() {
+			source := &fake_routing_api.FakeEventSource{}
+			source.NextStub = func() (routing_api.Event, error) {
+				if source.NextCallCount() == 1 {
+					return routing_api.Event{Action: "Upsert", Route: models.Route{}}, nil
+				}
+				return routing_api.Event{}, errors.New("boom")
+			}
+			client.SubscribeToEventsReturns(source, nil)
+
+			var events []routing_api.Event
+			err := commands.EventStream{Client: client}.StreamHttp(func(event routing_api.Event) {
+				events = append(events, event)
+			})
+			Expect(err).To(MatchError("boom"))
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Action).To(Equal("Upsert"))
+		})
+
+		It("reports every successful subscription before handling its events", func() {
+			source := &fake_routing_api.FakeEventSource{}
+			source.NextReturns(routing_api.Event{}, errors.New("boom"))
+			client.SubscribeToEventsReturns(source, nil)
+			client.SubscribeToEventsReturnsOnCall(0, nil, errors.New("unavailable"))
+
+			subscribed := make(chan int, 10)
+			stream := commands.EventStream{
+				Client: client,
+				Subscribed: func() {
+					subscribed <- source.NextCallCount()
+				},
+				Forever: true,
+				Backoff: time.Second,
+				Clock:   clock,
+			}
+			go stream.StreamHttp(func(routing_api.Event) {})
+			clock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(subscribed).Should(Receive(Equal(0)))
+			Expect(client.SubscribeToEventsCallCount()).To(Equal(2))
+		})
+
+		It("returns subscription errors", func() {
+			client.SubscribeToTcpEventsReturns(nil, errors.New("unauthorized"))
+			err := commands.EventStream{Client: client}.StreamTcp(func(routing_api.TcpEvent) {})
+			Expect(err).To(MatchError("unauthorized"))
+		})
+
+		Context("with max retries", func() {
+			It("leaves retrying the HTTP subscription to the routing-api client", func() {
+				source := &fake_routing_api.FakeEventSource{}
+				source.NextReturns(routing_api.Event{}, errors.New("boom"))
+				client.SubscribeToEventsWithMaxRetriesReturns(source, nil)
+
+				err := commands.EventStream{Client: client, MaxRetries: 3}.StreamHttp(func(routing_api.Event) {})
+				Expect(err).To(MatchError("boom"))
+
+				Expect(client.SubscribeToEventsWithMaxRetriesCallCount()).To(Equal(1))
+				Expect(client.SubscribeToEventsWithMaxRetriesArgsForCall(0)).To(Equal(uint16(3)))
+				Expect(client.SubscribeToEventsCallCount()).To(BeZero())
+			})
+
+			It("leaves retrying the TCP subscription to the routing-api client", func() {
+				client.SubscribeToTcpEventsWithMaxRetriesReturns(nil, errors.New("unavailable"))
+
+				err := commands.EventStream{Client: client, MaxRetries: 3}.StreamTcp(func(routing_api.TcpEvent) {})
+				Expect(err).To(MatchError("unavailable"))
+
+				Expect(client.SubscribeToTcpEventsWithMaxRetriesCallCount()).To(Equal(1))
+				Expect(client.SubscribeToTcpEventsWithMaxRetriesArgsForCall(0)).To(Equal(uint16(3)))
+				Expect(client.SubscribeToTcpEventsCallCount()).To(BeZero())
+			})
+		})
+
+		Context("forever", func() {
+			var (
+				stream   commands.EventStream
+				attempts chan time.Duration
+			)
+
+			BeforeEach(func() {
+				attempts = make(chan time.Duration, 10)
+				stream = commands.EventStream{
+					Client:  client,
+					Forever: true,
+					Backoff: time.Second,
+					Reconnecting: func(attempt int, delay time.Duration, err error) {
+						attempts <- delay
+					},
+					Clock: clock,
+				}
+				source := &fake_routing_api.FakeEventSource{}
+				source.NextReturns(routing_api.Event{}, errors.New("boom"))
+				client.SubscribeToEventsReturns(source, nil)
+			})
+
+			It("resubscribes with a doubling backoff up to a minute", func() {
+				go stream.StreamHttp(func(routing_api.Event) {})
+
+				for _, delay := range []time.Duration{1, 2, 4, 8, 16, 32, 60, 60} {
+					Eventually(attempts).Should(Receive(Equal(delay * time.Second)))
+					clock.WaitForWatcherAndIncrement(delay * time.Second)
+				}
+			})
+
+			It("retries failed subscriptions", func() {
+				client.SubscribeToTcpEventsReturns(nil, errors.New("unavailable"))
+
+				go stream.StreamTcp(func(routing_api.TcpEvent) {})
+
+				Eventually(attempts).Should(Receive())
+				clock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(attempts).Should(Receive())
+				Expect(client.SubscribeToTcpEventsCallCount()).To(Equal(2))
+			})
+
+			It("subscribes through the routing-api client's retries", func() {
+				stream.MaxRetries = 2
+				client.SubscribeToEventsWithMaxRetriesReturns(nil, errors.New("unavailable"))
+
+				go stream.StreamHttp(func(routing_api.Event) {})
+
+				Eventually(attempts).Should(Receive(Equal(time.Second)))
+				clock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(attempts).Should(Receive(Equal(2 * time.Second)))
+				Expect(client.SubscribeToEventsWithMaxRetriesCallCount()).To(Equal(2))
+				Expect(client.SubscribeToEventsCallCount()).To(BeZero())
+			})
+
+			It("starts counting the failures again after receiving an event", func() {
+				first := &fake_routing_api.FakeEventSource{}
+				first.NextReturnsOnCall(0, routing_api.Event{}, errors.New("boom"))
+				second := &fake_routing_api.FakeEventSource{}
+				second.NextReturnsOnCall(0, routing_api.Event{Action: "Upsert"}, nil)
+				second.NextReturnsOnCall(1, routing_api.Event{}, errors.New("boom"))
+				client.SubscribeToEventsReturnsOnCall(0, first, nil)
+				client.SubscribeToEventsReturnsOnCall(1, second, nil)
+
+				go stream.StreamHttp(func(routing_api.Event) {})
+
+				Eventually(attempts).Should(Receive(Equal(time.Second)))
+				clock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(attempts).Should(Receive(Equal(time.Second)))
+			})
+
+			It("waits at least the minimum backoff", func() {
+				stream.Backoff = 0
+
+				go stream.StreamHttp(func(routing_api.Event) {})
+
+				Eventually(attempts).Should(Receive(Equal(100 * time.Millisecond)))
+				clock.WaitForWatcherAndIncrement(100 * time.Millisecond)
+				Eventually(attempts).Should(Receive(Equal(200 * time.Millisecond)))
+			})
+		})
+	})
+
+	Describe("TokenRefresher", func() {
+		var (
+			refresher *commands.TokenRefresher
+			stop      chan struct{}
+			fetches   chan struct{}
+		)
+
+		BeforeEach(func() {
+			fetches = make(chan struct{}, 10)
+			refresher = &commands.TokenRefresher{
+				Client: client,
+				Fetch: func() (string, time.Time, error) {
+					fetches <- struct{}{}
+					return "new-token", clock.Now().Add(10 * time.Minute), nil
+				},
+				Expiry:        clock.Now().Add(10 * time.Minute),
+				Buffer:        30 * time.Second,
+				RetryInterval: 5 * time.Second,
+				Clock:         clock,
+			}
+			stop = make(chan struct{})
+		})
+
+		AfterEach(func() {
+			close(stop)
+		})
+
+		It("refreshes the token before it expires and resubscribes", func() {
+			first := blockingEventSource()
+			second := &fake_routing_api.FakeEventSource{}
+			second.NextStub = func() (routing_api.Event, error) {
+				return routing_api.Event{}, errors.New("boom")
+			}
+			client.SubscribeToEventsReturnsOnCall(0, first, nil)
+			client.SubscribeToEventsReturnsOnCall(1, second, nil)
+
+			resubscribed := 0
+			stream := commands.EventStream{
+				Client:       client,
+				Refresher:    refresher,
+				Resubscribed: func() { resubscribed++ },
+			}
+			result := make(chan error)
+			go func() {
+				result <- stream.StreamHttp(func(routing_api.Event) {})
+			}()
+			Eventually(first.NextCallCount).Should(Equal(1))
+
+			go refresher.Run(stop)
+			clock.WaitForWatcherAndIncrement(9*time.Minute + 29*time.Second)
+			Consistently(fetches).ShouldNot(Receive())
+
+			clock.Increment(time.Second)
+			Eventually(fetches).Should(Receive())
+
+			Eventually(result).Should(Receive(MatchError("boom")))
+			Expect(client.SetTokenCallCount()).To(Equal(1))
+			Expect(client.SetTokenArgsForCall(0)).To(Equal("new-token"))
+			Expect(client.SubscribeToEventsCallCount()).To(Equal(2))
+			Expect(resubscribed).To(Equal(1))
+		})
+
+		It("does not replace the token while HTTP and TCP streams subscribe", func() {
+			// Like the routing-api client, the fake reads and writes the
+			// token without synchronization, so -race reports overlaps.
+			token := "old-token"
+			var subscribedWith []string
+			client.SetTokenStub = func(t string) { token = t }
+			client.SubscribeToEventsStub = func() (routing_api.EventSource, error) {
+				subscribedWith = append(subscribedWith, token)
+				if client.SubscribeToEventsCallCount() == 1 {
+					return blockingEventSource(), nil
+				}
+				return nil, errors.New("done")
+			}
+			client.SubscribeToTcpEventsStub = func() (routing_api.TcpEventSource, error) {
+				subscribedWith = append(subscribedWith, token)
+				if client.SubscribeToTcpEventsCallCount() == 1 {
+					return blockingTcpEventSource(), nil
+				}
+				return nil, errors.New("done")
+			}
+
+			stream := commands.EventStream{Client: client, Refresher: refresher}
+			httpResult := make(chan error)
+			tcpResult := make(chan error)
+			go func() {
+				httpResult <- stream.StreamHttp(func(routing_api.Event) {})
+			}()
+			go func() {
+				tcpResult <- stream.StreamTcp(func(routing_api.TcpEvent) {})
+			}()
+			Eventually(client.SubscribeToEventsCallCount).Should(Equal(1))
+			Eventually(client.SubscribeToTcpEventsCallCount).Should(Equal(1))
+
+			go refresher.Run(stop)
+			clock.WaitForWatcherAndIncrement(9*time.Minute + 30*time.Second)
+
+			Eventually(httpResult).Should(Receive(MatchError("done")))
+			Eventually(tcpResult).Should(Receive(MatchError("done")))
+			Expect(subscribedWith).To(ConsistOf("old-token", "old-token", "new-token", "new-token"))
+		})
+
+		It("retries failed fetches after the retry interval", func() {
+			failures := make(chan error, 10)
+			refresher.Fetch = func() (string, time.Time, error) {
+				fetches <- struct{}{}
+				return "", time.Time{}, errors.New("uaa down")
+			}
+			refresher.Failed = func(err error) { failures <- err }
+
+			go refresher.Run(stop)
+			clock.WaitForWatcherAndIncrement(9*time.Minute + 30*time.Second)
+			Eventually(fetches).Should(Receive())
+			Eventually(failures).Should(Receive(MatchError("uaa down")))
+
+			clock.WaitForWatcherAndIncrement(5 * time.Second)
+			Eventually(fetches).Should(Receive())
+			Expect(client.SetTokenCallCount()).To(Equal(0))
+		})
+
+		It("does not refresh tokens without an expiry", func() {
+			refresher.Expiry = time.Time{}
+			done := make(chan struct{})
+			go func() {
+				refresher.Run(stop)
+				close(done)
+			}()
+			Eventually(done).Should(BeClosed())
+			Expect(fetches).NotTo(Receive())
+		})
+	})
+})
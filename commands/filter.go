@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// Glob compiles a hostname pattern in which * matches any sequence of
+// characters, including dots and the slashes of context paths, and ?
+// matches a single character. Hostnames are matched case-insensitively.
+func Glob(pattern string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, `.*`)
+	expr = strings.ReplaceAll(expr, `\?`, `.`)
+	return regexp.MustCompile(`(?i)^` + expr + `$`)
+}
+
+// ParseNetwork parses a CIDR such as 10.0.16.0/20. A single IP address is
+// treated as a network holding only that address.
+func ParseNetwork(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		return network, err
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", value)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// EventFilter selects route events. Every filter that is set has to match,
+// and a filter matches when any of its values does. HTTP routes have no
+// router group or isolation segment, so filtering by those only lets TCP
+// events through.
+type EventFilter struct {
+	// Actions are matched case-insensitively, e.g. upsert or delete.
+	Actions []string
+	// Routes match the hostname of HTTP routes and the SNI hostname of TCP
+	// route mappings.
+	Routes []*regexp.Regexp
+	// Networks match the IP of HTTP routes and the backend IP of TCP route
+	// mappings.
+	Networks []*net.IPNet
+	// Ports match the port of HTTP routes, and the frontend or the backend
+	// port of TCP route mappings.
+	Ports             []int
+	RouterGroupGuids  []string
+	IsolationSegments []string
+}
+
+// MatchHttp reports whether the HTTP route event passes the filter.
+func (f EventFilter) MatchHttp(event routing_api.Event) bool {
+	if len(f.RouterGroupGuids) > 0 || len(f.IsolationSegments) > 0 {
+		return false
+	}
+
+	route := event.Route
+	return f.matchAction(event.Action) &&
+		(len(f.Routes) == 0 || matchAny(f.Routes, route.Route)) &&
+		(len(f.Networks) == 0 || containsIP(f.Networks, route.IP)) &&
+		(len(f.Ports) == 0 || containsPort(f.Ports, int(route.Port)))
+}
+
+// MatchTcp reports whether the TCP route event passes the filter.
+func (f EventFilter) MatchTcp(event routing_api.TcpEvent) bool {
+	mapping := event.TcpRouteMapping
+	return f.matchAction(event.Action) &&
+		(len(f.Routes) == 0 || matchSniHostname(f.Routes, mapping)) &&
+		(len(f.Networks) == 0 || containsIP(f.Networks, mapping.HostIP)) &&
+		(len(f.Ports) == 0 || containsPort(f.Ports, int(mapping.ExternalPort), int(mapping.HostPort))) &&
+		(len(f.RouterGroupGuids) == 0 || containsString(f.RouterGroupGuids, mapping.RouterGroupGuid)) &&
+		(len(f.IsolationSegments) == 0 || containsString(f.IsolationSegments, mapping.IsolationSegment))
+}
+
+func (f EventFilter) matchAction(action string) bool {
+	if len(f.Actions) == 0 {
+		return true
+	}
+	for _, a := range f.Actions {
+		if strings.EqualFold(a, action) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSniHostname(patterns []*regexp.Regexp, mapping models.TcpRouteMapping) bool {
+	return mapping.SniHostname != nil && matchAny(patterns, *mapping.SniHostname)
+}
+
+func matchAny(patterns []*regexp.Regexp, value string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIP(networks []*net.IPNet, value string) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPort(ports []int, values ...int) bool {
+	for _, port := range ports {
+		for _, value := range values {
+			if port == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package commands_test
+
+import (
+	"net"
+	"regexp"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filters", func() {
+	Describe("Glob", func() {
+		It("matches any characters for *, including dots and slashes", func() {
+			glob := commands.Glob("*.example.com")
+			Expect(glob.MatchString("app.example.com")).To(BeTrue())
+			Expect(glob.MatchString("a.b.example.com")).To(BeTrue())
+			Expect(glob.MatchString("APP.Example.com")).To(BeTrue())
+			Expect(glob.MatchString("example.com")).To(BeFalse())
+			Expect(commands.Glob("app.example.com/*").MatchString("app.example.com/api/v1")).To(BeTrue())
+		})
+
+		It("takes other characters literally", func() {
+			glob := commands.Glob("app?.example.com")
+			Expect(glob.MatchString("app1.example.com")).To(BeTrue())
+			Expect(glob.MatchString("app1xexample.com")).To(BeFalse())
+		})
+	})
+
+	Describe("ParseNetwork", func() {
+		It("parses CIDRs", func() {
+			network, err := commands.ParseNetwork("10.0.16.0/20")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(network.Contains(net.ParseIP("10.0.31.255"))).To(BeTrue())
+			Expect(network.Contains(net.ParseIP("10.0.32.0"))).To(BeFalse())
+		})
+
+		It("parses single addresses", func() {
+			network, err := commands.ParseNetwork("10.0.16.1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(network.Contains(net.ParseIP("10.0.16.1"))).To(BeTrue())
+			Expect(network.Contains(net.ParseIP("10.0.16.2"))).To(BeFalse())
+
+			network, err = commands.ParseNetwork("fd00::1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(network.Contains(net.ParseIP("fd00::1"))).To(BeTrue())
+		})
+
+		It("rejects invalid addresses", func() {
+			_, err := commands.ParseNetwork("10.0.16")
+			Expect(err).To(HaveOccurred())
+			_, err = commands.ParseNetwork("10.0.16.0/33")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("EventFilter", func() {
+		var (
+			httpEvent routing_api.Event
+			tcpEvent  routing_api.TcpEvent
+		)
+
+		BeforeEach(func() {
+			httpEvent = routing_api.Event{
+				Action: "Upsert",
+				Route:  models.NewRoute("app.example.com", 8080, "10.0.16.5", "log-guid", "", 60),
+			}
+			sni := "tls.example.com"
+			tcpEvent = routing_api.TcpEvent{
+				Action:          "Delete",
+				TcpRouteMapping: models.NewTcpRouteMapping("group-guid", 1024, "10.0.32.5", 61000, 0, "", &sni, 60, models.ModificationTag{}),
+			}
+			tcpEvent.TcpRouteMapping.IsolationSegment = "iso"
+		})
+
+		It("lets every event through when empty", func() {
+			Expect(commands.EventFilter{}.MatchHttp(httpEvent)).To(BeTrue())
+			Expect(commands.EventFilter{}.MatchTcp(tcpEvent)).To(BeTrue())
+		})
+
+		It("filters by action", func() {
+			filter := commands.EventFilter{Actions: []string{"upsert"}}
+			Expect(filter.MatchHttp(httpEvent)).To(BeTrue())
+			Expect(filter.MatchTcp(tcpEvent)).To(BeFalse())
+		})
+
+		It("filters by hostname", func() {
+			filter := commands.EventFilter{Routes: []*regexp.Regexp{commands.Glob("*.example.com")}}
+			Expect(filter.MatchHttp(httpEvent)).To(BeTrue())
+			Expect(filter.MatchTcp(tcpEvent)).To(BeTrue())
+
+			filter = commands.EventFilter{Routes: []*regexp.Regexp{commands.Glob("other.com"), commands.Glob("app.*")}}
+			Expect(filter.MatchHttp(httpEvent)).To(BeTrue())
+			Expect(filter.MatchTcp(tcpEvent)).To(BeFalse())
+		})
+
+		It("filters by network", func() {
+			network, err := commands.ParseNetwork("10.0.16.0/20")
+			Expect(err).NotTo(HaveOccurred())
+			filter := commands.EventFilter{Networks: []*net.IPNet{network}}
+			Expect(filter.MatchHttp(httpEvent)).To(BeTrue())
+			Expect(filter.MatchTcp(tcpEvent)).To(BeFalse())
+		})
+
+		It("filters by frontend and backend ports", func() {
+			Expect(commands.EventFilter{Ports: []int{8080}}.MatchHttp(httpEvent)).To(BeTrue())
+			Expect(commands.EventFilter{Ports: []int{1024}}.MatchTcp(tcpEvent)).To(BeTrue())
+			Expect(commands.EventFilter{Ports: []int{61000}}.MatchTcp(tcpEvent)).To(BeTrue())
+			Expect(commands.EventFilter{Ports: []int{1025}}.MatchTcp(tcpEvent)).To(BeFalse())
+		})
+
+		It("only lets TCP events through when filtering by router group or isolation segment", func() {
+			filter := commands.EventFilter{RouterGroupGuids: []string{"group-guid"}}
+			Expect(filter.MatchHttp(httpEvent)).To(BeFalse())
+			Expect(filter.MatchTcp(tcpEvent)).To(BeTrue())
+
+			filter = commands.EventFilter{IsolationSegments: []string{"other"}}
+			Expect(filter.MatchHttp(httpEvent)).To(BeFalse())
+			Expect(filter.MatchTcp(tcpEvent)).To(BeFalse())
+		})
+
+		It("requires every filter to match", func() {
+			filter := commands.EventFilter{Actions: []string{"upsert"}, Ports: []int{9090}}
+			Expect(filter.MatchHttp(httpEvent)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,249 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+var severityOrder = map[string]int{SeverityError: 0, SeverityWarning: 1, SeverityInfo: 2}
+
+// LintProblem is a suspicious state of the routes of Subject, a hostname or
+// a TCP frontend.
+type LintProblem struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Subject  string `json:"subject"`
+	Message  string `json:"message"`
+}
+
+// LintOptions configure the checks of Lint.
+type LintOptions struct {
+	// MaxTTL is the longest TTL in seconds that is not reported.
+	MaxTTL int
+}
+
+// Lint fetches the HTTP routes, TCP route mappings and router groups, and
+// reports the problems found in them, errors first.
+func Lint(client routing_api.Client, options LintOptions) ([]LintProblem, error) {
+	routes, err := List(client)
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := ListTcp(client, nil)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := ListRouterGroups(client)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := LintRoutes(routes, options)
+	problems = append(problems, LintTcpRoutes(mappings, groups, options)...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Severity != problems[j].Severity {
+			return severityOrder[problems[i].Severity] < severityOrder[problems[j].Severity]
+		}
+		return problems[i].Subject < problems[j].Subject
+	})
+	return problems, nil
+}
+
+// CountSeverities counts the problems by severity.
+func CountSeverities(problems []LintProblem) map[string]int {
+	counts := map[string]int{}
+	for _, problem := range problems {
+		counts[problem.Severity]++
+	}
+	return counts
+}
+
+// LintRoutes reports routes with a route service on only some backends,
+// backends of several log guids and TTLs above the maximum.
+func LintRoutes(routes []models.Route, options LintOptions) []LintProblem {
+	problems := []LintProblem{}
+
+	var names []string
+	byRoute := map[string][]models.Route{}
+	for _, route := range routes {
+		if _, found := byRoute[route.Route]; !found {
+			names = append(names, route.Route)
+		}
+		byRoute[route.Route] = append(byRoute[route.Route], route)
+	}
+
+	for _, name := range names {
+		backends := byRoute[name]
+
+		urls := map[string]int{}
+		logGuids := map[string]int{}
+		for _, route := range backends {
+			urls[route.RouteServiceUrl]++
+			logGuids[route.LogGuid]++
+		}
+
+		if len(urls) > 1 {
+			var counts []string
+			for _, url := range sortedKeys(urls) {
+				if url == "" {
+					counts = append(counts, fmt.Sprintf("%d without", urls[url]))
+				} else {
+					counts = append(counts, fmt.Sprintf("%d with %s", urls[url], url))
+				}
+			}
+			problems = append(problems, LintProblem{
+				Severity: SeverityError,
+				Check:    "route-service",
+				Subject:  name,
+				Message:  "backends differ in route service: " + strings.Join(counts, ", "),
+			})
+		}
+
+		if len(logGuids) > 1 {
+			problems = append(problems, LintProblem{
+				Severity: SeverityInfo,
+				Check:    "shared-route",
+				Subject:  name,
+				Message:  "backends have different log guids: " + strings.Join(sortedKeys(logGuids), ", "),
+			})
+		}
+
+		for _, route := range backends {
+			if problem, ok := lintTTL(route.TTL, options); ok {
+				problem.Subject = routeKey(route)
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	return problems
+}
+
+// LintTcpRoutes reports TCP route mappings sharing a frontend port with and
+// without an SNI hostname, mappings of unknown router groups or outside of
+// their reservable ports, and TTLs above the maximum.
+func LintTcpRoutes(mappings []models.TcpRouteMapping, groups []models.RouterGroup, options LintOptions) []LintProblem {
+	problems := []LintProblem{}
+
+	groupsByGuid := make(map[string]models.RouterGroup, len(groups))
+	for _, group := range groups {
+		groupsByGuid[group.Guid] = group
+	}
+
+	var frontends []string
+	byFrontend := map[string][]models.TcpRouteMapping{}
+	for _, mapping := range mappings {
+		frontend := mapping.RouterGroupGuid + ":" + strconv.Itoa(int(mapping.ExternalPort))
+		if group, found := groupsByGuid[mapping.RouterGroupGuid]; found {
+			frontend = group.Name + ":" + strconv.Itoa(int(mapping.ExternalPort))
+		}
+		if _, found := byFrontend[frontend]; !found {
+			frontends = append(frontends, frontend)
+		}
+		byFrontend[frontend] = append(byFrontend[frontend], mapping)
+	}
+
+	for _, frontend := range frontends {
+		backends := byFrontend[frontend]
+		first := backends[0]
+
+		group, found := groupsByGuid[first.RouterGroupGuid]
+		switch {
+		case !found:
+			problems = append(problems, LintProblem{
+				Severity: SeverityError,
+				Check:    "router-group",
+				Subject:  frontend,
+				Message:  "router group " + first.RouterGroupGuid + " does not exist",
+			})
+		case group.ReservablePorts != "" && !reservesPort(group.ReservablePorts, int(first.ExternalPort)):
+			problems = append(problems, LintProblem{
+				Severity: SeverityWarning,
+				Check:    "router-group",
+				Subject:  frontend,
+				Message:  fmt.Sprintf("port %d is not among the reservable ports %s", first.ExternalPort, group.ReservablePorts),
+			})
+		}
+
+		withSni, withoutSni := 0, 0
+		for _, mapping := range backends {
+			if mapping.SniHostname != nil && *mapping.SniHostname != "" {
+				withSni++
+			} else {
+				withoutSni++
+			}
+		}
+		if withSni > 0 && withoutSni > 0 {
+			problems = append(problems, LintProblem{
+				Severity: SeverityError,
+				Check:    "tcp-sni",
+				Subject:  frontend,
+				Message:  fmt.Sprintf("mappings sharing the frontend port differ in SNI: %d with and %d without an SNI hostname", withSni, withoutSni),
+			})
+		}
+
+		for _, mapping := range backends {
+			if problem, ok := lintTTL(mapping.TTL, options); ok {
+				problem.Subject = strings.TrimSpace(tcpRouteKey(mapping))
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	return problems
+}
+
+func lintTTL(ttl *int, options LintOptions) (LintProblem, bool) {
+	if options.MaxTTL <= 0 || ttl == nil || *ttl <= options.MaxTTL {
+		return LintProblem{}, false
+	}
+	return LintProblem{
+		Severity: SeverityWarning,
+		Check:    "ttl",
+		Message:  fmt.Sprintf("ttl %ds is longer than %ds, the route stays that long after its backend stops registering it", *ttl, options.MaxTTL),
+	}, true
+}
+
+// reservesPort reports whether the port is among the reservable ports, which
+// are assumed to be valid.
+func reservesPort(ports models.ReservablePorts, port int) bool {
+	for _, entry := range strings.Split(string(ports), ",") {
+		bounds := strings.Split(strings.TrimSpace(entry), "-")
+		start, err := parsePort(bounds[0])
+		if err != nil {
+			continue
+		}
+		end := start
+		if len(bounds) == 2 {
+			end, err = parsePort(bounds[1])
+			if err != nil {
+				continue
+			}
+		}
+		if uint64(port) >= start && uint64(port) <= end {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lint", func() {
+	var options commands.LintOptions
+
+	BeforeEach(func() {
+		options = commands.LintOptions{MaxTTL: 3600}
+	})
+
+	Describe(".LintRoutes", func() {
+		It("finds nothing wrong with consistent routes", func() {
+			problems := commands.LintRoutes([]models.Route{
+				models.NewRoute("a.example.com", 8080, "10.0.0.1", "app", "https://rs.example.com", 60),
+				models.NewRoute("a.example.com", 8080, "10.0.0.2", "app", "https://rs.example.com", 60),
+			}, options)
+			Expect(problems).NotTo(BeNil())
+			Expect(problems).To(BeEmpty())
+		})
+
+		It("reports route services on only some backends", func() {
+			problems := commands.LintRoutes([]models.Route{
+				models.NewRoute("a.example.com", 8080, "10.0.0.1", "app", "https://rs.example.com", 60),
+				models.NewRoute("a.example.com", 8080, "10.0.0.2", "app", "", 60),
+				models.NewRoute("a.example.com", 8080, "10.0.0.3", "app", "", 60),
+			}, options)
+			Expect(problems).To(Equal([]commands.LintProblem{{
+				Severity: commands.SeverityError,
+				Check:    "route-service",
+				Subject:  "a.example.com",
+				Message:  "backends differ in route service: 2 without, 1 with https://rs.example.com",
+			}}))
+		})
+
+		It("reports routes shared by several log guids and long TTLs", func() {
+			problems := commands.LintRoutes([]models.Route{
+				models.NewRoute("a.example.com", 8080, "10.0.0.1", "app-a", "", 60),
+				models.NewRoute("a.example.com", 8080, "10.0.0.2", "app-b", "", 86400),
+			}, options)
+			Expect(problems).To(Equal([]commands.LintProblem{
+				{
+					Severity: commands.SeverityInfo,
+					Check:    "shared-route",
+					Subject:  "a.example.com",
+					Message:  "backends have different log guids: app-a, app-b",
+				},
+				{
+					Severity: commands.SeverityWarning,
+					Check:    "ttl",
+					Subject:  "a.example.com 10.0.0.2:8080",
+					Message:  "ttl 86400s is longer than 3600s, the route stays that long after its backend stops registering it",
+				},
+			}))
+		})
+	})
+
+	Describe(".LintTcpRoutes", func() {
+		var groups []models.RouterGroup
+
+		BeforeEach(func() {
+			groups = []models.RouterGroup{{Guid: "group-guid", Name: "default-tcp", Type: models.RouterGroup_TCP, ReservablePorts: "1024-1033"}}
+		})
+
+		It("finds nothing wrong with mappings on reservable ports", func() {
+			problems := commands.LintTcpRoutes([]models.TcpRouteMapping{
+				models.NewTcpRouteMapping("group-guid", 1024, "10.0.0.1", 61000, 0, "", nil, 60, models.ModificationTag{}),
+			}, groups, options)
+			Expect(problems).NotTo(BeNil())
+			Expect(problems).To(BeEmpty())
+		})
+
+		It("reports mappings sharing a frontend port with and without SNI", func() {
+			sni := "tls.example.com"
+			problems := commands.LintTcpRoutes([]models.TcpRouteMapping{
+				models.NewTcpRouteMapping("group-guid", 1024, "10.0.0.1", 61000, 0, "", &sni, 60, models.ModificationTag{}),
+				models.NewTcpRouteMapping("group-guid", 1024, "10.0.0.2", 61000, 0, "", nil, 60, models.ModificationTag{}),
+			}, groups, options)
+			Expect(problems).To(Equal([]commands.LintProblem{{
+				Severity: commands.SeverityError,
+				Check:    "tcp-sni",
+				Subject:  "default-tcp:1024",
+				Message:  "mappings sharing the frontend port differ in SNI: 1 with and 1 without an SNI hostname",
+			}}))
+		})
+
+		It("reports unknown router groups and ports that are not reservable", func() {
+			problems := commands.LintTcpRoutes([]models.TcpRouteMapping{
+				models.NewTcpRouteMapping("group-guid", 2000, "10.0.0.1", 61000, 0, "", nil, 60, models.ModificationTag{}),
+				models.NewTcpRouteMapping("gone-guid", 1024, "10.0.0.1", 61001, 0, "", nil, 60, models.ModificationTag{}),
+			}, groups, options)
+			Expect(problems).To(Equal([]commands.LintProblem{
+				{
+					Severity: commands.SeverityWarning,
+					Check:    "router-group",
+					Subject:  "default-tcp:2000",
+					Message:  "port 2000 is not among the reservable ports 1024-1033",
+				},
+				{
+					Severity: commands.SeverityError,
+					Check:    "router-group",
+					Subject:  "gone-guid:1024",
+					Message:  "router group gone-guid does not exist",
+				},
+			}))
+		})
+	})
+
+	Describe(".Lint", func() {
+		It("returns an empty list for a clean routing table", func() {
+			problems, err := commands.Lint(&fake_routing_api.FakeClient{}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).NotTo(BeNil())
+			Expect(problems).To(BeEmpty())
+		})
+
+		It("sorts the problems by severity", func() {
+			client := &fake_routing_api.FakeClient{}
+			client.RoutesReturns([]models.Route{
+				models.NewRoute("b.example.com", 8080, "10.0.0.1", "app", "", 86400),
+				models.NewRoute("a.example.com", 8080, "10.0.0.1", "app", "https://rs.example.com", 60),
+				models.NewRoute("a.example.com", 8080, "10.0.0.2", "app", "", 60),
+			}, nil)
+
+			problems, err := commands.Lint(client, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(HaveLen(2))
+			Expect(problems[0].Severity).To(Equal(commands.SeverityError))
+			Expect(problems[1].Severity).To(Equal(commands.SeverityWarning))
+			Expect(commands.CountSeverities(problems)).To(Equal(map[string]int{
+				commands.SeverityError:   1,
+				commands.SeverityWarning: 1,
+			}))
+		})
+	})
+})
@@ -1,10 +1,143 @@
 package commands
 
 import (
+	"bytes"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
 	routing_api "code.cloudfoundry.org/routing-api"
 	"code.cloudfoundry.org/routing-api/models"
 )
 
+// RouteSortFields are the fields routes can be sorted by.
+var RouteSortFields = []string{"route", "ip", "port", "ttl", "log_guid", "route_service_url"}
+
 func List(client routing_api.Client) ([]models.Route, error) {
 	return client.Routes()
 }
+
+// RouteFilter selects routes. Every filter that is set has to match, and a
+// filter matches when any of its values does.
+type RouteFilter struct {
+	Routes   []*regexp.Regexp
+	Networks []*net.IPNet
+	Ports    []int
+	LogGuids []string
+	// HasRouteService keeps only the routes with a route service.
+	HasRouteService bool
+}
+
+// Match reports whether the route passes the filter.
+func (f RouteFilter) Match(route models.Route) bool {
+	return (len(f.Routes) == 0 || matchAny(f.Routes, route.Route)) &&
+		(len(f.Networks) == 0 || containsIP(f.Networks, route.IP)) &&
+		(len(f.Ports) == 0 || containsPort(f.Ports, int(route.Port))) &&
+		(len(f.LogGuids) == 0 || containsString(f.LogGuids, route.LogGuid)) &&
+		(!f.HasRouteService || route.RouteServiceUrl != "")
+}
+
+// MatchTcp reports whether the TCP route mapping passes the filter. Routes
+// match its SNI hostname, Networks its backend IP and Ports its frontend or
+// backend port. TCP route mappings have no log guid or route service, so
+// filtering by those lets none through.
+func (f RouteFilter) MatchTcp(mapping models.TcpRouteMapping) bool {
+	if len(f.LogGuids) > 0 || f.HasRouteService {
+		return false
+	}
+	return (len(f.Routes) == 0 || matchSniHostname(f.Routes, mapping)) &&
+		(len(f.Networks) == 0 || containsIP(f.Networks, mapping.HostIP)) &&
+		(len(f.Ports) == 0 || containsPort(f.Ports, int(mapping.ExternalPort), int(mapping.HostPort)))
+}
+
+// ListOptions filter, sort and limit the listed routes.
+type ListOptions struct {
+	Filter RouteFilter
+	// SortBy is one of RouteSortFields, routes are listed in the order of the
+	// routing-api when it is empty.
+	SortBy string
+	// Limit lists at most that many routes when positive.
+	Limit int
+}
+
+// ListFiltered lists the routes passing the filter, sorted and limited as
+// given by the options.
+func ListFiltered(client routing_api.Client, options ListOptions) ([]models.Route, error) {
+	less, err := routeOrder(options.SortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := List(client)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]models.Route, 0, len(routes))
+	for _, route := range routes {
+		if options.Filter.Match(route) {
+			filtered = append(filtered, route)
+		}
+	}
+
+	if less != nil {
+		sort.SliceStable(filtered, func(i, j int) bool { return less(filtered[i], filtered[j]) })
+	}
+	if options.Limit > 0 && len(filtered) > options.Limit {
+		filtered = filtered[:options.Limit]
+	}
+	return filtered, nil
+}
+
+// ParseRoutePattern compiles a pattern written as /regex/, or else a glob.
+// Regular expressions are matched case-insensitively and unanchored.
+func ParseRoutePattern(value string) (*regexp.Regexp, error) {
+	if len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		return regexp.Compile("(?i)" + value[1:len(value)-1])
+	}
+	return Glob(value), nil
+}
+
+func routeOrder(field string) (func(a, b models.Route) bool, error) {
+	switch field {
+	case "":
+		return nil, nil
+	case "route":
+		return func(a, b models.Route) bool { return a.Route < b.Route }, nil
+	case "ip":
+		return func(a, b models.Route) bool { return compareIPs(a.IP, b.IP) < 0 }, nil
+	case "port":
+		return func(a, b models.Route) bool { return a.Port < b.Port }, nil
+	case "ttl":
+		return func(a, b models.Route) bool { return ttlValue(a.TTL) < ttlValue(b.TTL) }, nil
+	case "log_guid":
+		return func(a, b models.Route) bool { return a.LogGuid < b.LogGuid }, nil
+	case "route_service_url":
+		return func(a, b models.Route) bool { return a.RouteServiceUrl < b.RouteServiceUrl }, nil
+	}
+	return nil, fmt.Errorf("cannot sort by %s, must be one of %s", field, strings.Join(RouteSortFields, ", "))
+}
+
+// compareIPs orders IPs numerically, and values that are no IPs after them.
+func compareIPs(a, b string) int {
+	ipA, ipB := net.ParseIP(a).To16(), net.ParseIP(b).To16()
+	switch {
+	case ipA == nil && ipB == nil:
+		return strings.Compare(a, b)
+	case ipA == nil:
+		return 1
+	case ipB == nil:
+		return -1
+	}
+	return bytes.Compare(ipA, ipB)
+}
+
+// ttlValue orders unset TTLs first.
+func ttlValue(ttl *int) int {
+	if ttl == nil {
+		return -1
+	}
+	return *ttl
+}
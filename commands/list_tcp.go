@@ -0,0 +1,15 @@
+package commands
+
+import (
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// ListTcp lists the TCP route mappings, restricted to the given isolation
+// segments when any are provided.
+func ListTcp(client routing_api.Client, isolationSegments []string) ([]models.TcpRouteMapping, error) {
+	if len(isolationSegments) > 0 {
+		return client.FilteredTcpRouteMappings(isolationSegments)
+	}
+	return client.TcpRouteMappings()
+}
@@ -0,0 +1,44 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(".ListTcp", func() {
+	var (
+		client   *fake_routing_api.FakeClient
+		mappings []models.TcpRouteMapping
+	)
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+		mappings = []models.TcpRouteMapping{
+			models.NewTcpRouteMapping("router-group-guid", 1234, "1.2.3.4", 6789, 0, "instance-guid", nil, 60, models.ModificationTag{}),
+		}
+		client.TcpRouteMappingsReturns(mappings, nil)
+		client.FilteredTcpRouteMappingsReturns(mappings, nil)
+	})
+
+	It("lists all tcp route mappings", func() {
+		mappingsList, err := commands.ListTcp(client, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.TcpRouteMappingsCallCount()).To(Equal(1))
+		Expect(client.FilteredTcpRouteMappingsCallCount()).To(Equal(0))
+		Expect(mappingsList).To(Equal(mappings))
+	})
+
+	Context("when isolation segments are given", func() {
+		It("lists the tcp route mappings of those isolation segments", func() {
+			mappingsList, err := commands.ListTcp(client, []string{"is1", "is2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.TcpRouteMappingsCallCount()).To(Equal(0))
+			Expect(client.FilteredTcpRouteMappingsCallCount()).To(Equal(1))
+			Expect(client.FilteredTcpRouteMappingsArgsForCall(0)).To(Equal([]string{"is1", "is2"}))
+			Expect(mappingsList).To(Equal(mappings))
+		})
+	})
+})
@@ -2,6 +2,8 @@ package commands_test
 
 import (
 	"errors"
+	"net"
+	"regexp"
 
 	"code.cloudfoundry.org/routing-api-cli/commands"
 	"code.cloudfoundry.org/routing-api/fake_routing_api"
@@ -32,3 +34,87 @@ var _ = Describe(".List", func() {
 	})
 
 })
+
+var _ = Describe(".ListFiltered", func() {
+	var client *fake_routing_api.FakeClient
+
+	names := func(routes []models.Route) []string {
+		result := []string{}
+		for _, route := range routes {
+			result = append(result, route.Route)
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+		client.RoutesReturns([]models.Route{
+			models.NewRoute("b.example.com", 8080, "10.0.0.10", "team-a", "", 60),
+			models.NewRoute("a.example.com", 8081, "10.0.0.9", "team-b", "https://rs.example.com", 30),
+			models.NewRoute("c.example.org", 8080, "192.168.0.1", "team-a", "", 120),
+		}, nil)
+	})
+
+	It("lists every route without options", func() {
+		routes, err := commands.ListFiltered(client, commands.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(routes)).To(Equal([]string{"b.example.com", "a.example.com", "c.example.org"}))
+	})
+
+	It("lists the routes passing every filter", func() {
+		network, err := commands.ParseNetwork("10.0.0.0/8")
+		Expect(err).NotTo(HaveOccurred())
+
+		routes, err := commands.ListFiltered(client, commands.ListOptions{Filter: commands.RouteFilter{
+			Routes:   []*regexp.Regexp{commands.Glob("*.example.com")},
+			Networks: []*net.IPNet{network},
+			Ports:    []int{8080},
+			LogGuids: []string{"team-a"},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(routes)).To(Equal([]string{"b.example.com"}))
+	})
+
+	It("lists the routes with a route service", func() {
+		routes, err := commands.ListFiltered(client, commands.ListOptions{Filter: commands.RouteFilter{HasRouteService: true}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(routes)).To(Equal([]string{"a.example.com"}))
+	})
+
+	It("sorts IPs numerically", func() {
+		routes, err := commands.ListFiltered(client, commands.ListOptions{SortBy: "ip"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(routes)).To(Equal([]string{"a.example.com", "b.example.com", "c.example.org"}))
+	})
+
+	It("sorts and limits the routes", func() {
+		routes, err := commands.ListFiltered(client, commands.ListOptions{SortBy: "ttl", Limit: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(routes)).To(Equal([]string{"a.example.com", "b.example.com"}))
+	})
+
+	It("rejects unknown sort fields without listing", func() {
+		_, err := commands.ListFiltered(client, commands.ListOptions{SortBy: "color"})
+		Expect(err).To(MatchError("cannot sort by color, must be one of route, ip, port, ttl, log_guid, route_service_url"))
+		Expect(client.RoutesCallCount()).To(Equal(0))
+	})
+})
+
+var _ = Describe(".ParseRoutePattern", func() {
+	It("compiles globs", func() {
+		pattern, err := commands.ParseRoutePattern("*.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pattern.MatchString("a.example.com")).To(BeTrue())
+		Expect(pattern.MatchString("a.example.com.evil")).To(BeFalse())
+	})
+
+	It("compiles regular expressions between slashes", func() {
+		pattern, err := commands.ParseRoutePattern(`/^(a|b)\.example/`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pattern.MatchString("B.example.com")).To(BeTrue())
+		Expect(pattern.MatchString("c.example.com")).To(BeFalse())
+
+		_, err = commands.ParseRoutePattern("/(/")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// RewriteRule moves backend IPs from one network to another of the same
+// size, keeping their host part, e.g. 10.0.1.5 becomes 10.1.1.5 with
+// 10.0.0.0/16=10.1.0.0/16.
+type RewriteRule struct {
+	From *net.IPNet
+	To   *net.IPNet
+}
+
+// ParseRewriteRule parses a rule written as <from CIDR>=<to CIDR>.
+func ParseRewriteRule(value string) (RewriteRule, error) {
+	from, to, found := strings.Cut(value, "=")
+	if !found {
+		return RewriteRule{}, fmt.Errorf("%q is not written as <from CIDR>=<to CIDR>", value)
+	}
+
+	var rule RewriteRule
+	var err error
+	_, rule.From, err = net.ParseCIDR(strings.TrimSpace(from))
+	if err != nil {
+		return RewriteRule{}, err
+	}
+	_, rule.To, err = net.ParseCIDR(strings.TrimSpace(to))
+	if err != nil {
+		return RewriteRule{}, err
+	}
+
+	fromOnes, fromBits := rule.From.Mask.Size()
+	toOnes, toBits := rule.To.Mask.Size()
+	if fromOnes != toOnes || fromBits != toBits {
+		return RewriteRule{}, fmt.Errorf("%s and %s differ in size", rule.From, rule.To)
+	}
+	return rule, nil
+}
+
+// Rewrite returns the IP moved into the To network, and whether it was in
+// the From network.
+func (r RewriteRule) Rewrite(ip string) (string, bool) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || !r.From.Contains(parsed) {
+		return ip, false
+	}
+
+	from := parsed.To4()
+	if len(r.From.IP) == net.IPv6len {
+		from = parsed.To16()
+	}
+
+	rewritten := make(net.IP, len(from))
+	for i := range from {
+		rewritten[i] = r.To.IP[i] | from[i]&^r.From.Mask[i]
+	}
+	return rewritten.String(), true
+}
+
+// Migration copies routes from one routing-api to another, e.g. from an old
+// foundation to a new one.
+type Migration struct {
+	// Scope selects the routes to copy, all of them when it is empty.
+	Scope Scope
+	// Rewrites move the backend IPs, the first matching rule wins.
+	Rewrites []RewriteRule
+	// TTLOverride replaces the TTL of the copied routes when positive.
+	// Otherwise they keep the TTL of the source, and expire on the
+	// destination unless something registers them there in time.
+	TTLOverride int
+}
+
+// Plan compares the routes to copy with the ones registered with to. TCP
+// route mappings are moved to the router group of the same name, as the
+// guids differ between routing-apis.
+func (m Migration) Plan(from, to routing_api.Client) (Plan, error) {
+	registered, err := List(from)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	var routes []models.Route
+	for _, route := range registered {
+		if !m.Scope.Empty() && !m.Scope.ContainsRoute(route) {
+			continue
+		}
+		route.IP = m.rewrite(route.IP)
+		route.TTL = m.ttl(route.TTL)
+		route.ModificationTag = models.ModificationTag{}
+		routes = append(routes, route)
+	}
+
+	registeredTcp, err := ListTcp(from, nil)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	var mappings []models.TcpRouteMapping
+	for _, mapping := range registeredTcp {
+		if !m.Scope.Empty() && !m.Scope.ContainsTcpRoute(mapping) {
+			continue
+		}
+		mapping.HostIP = m.rewrite(mapping.HostIP)
+		mapping.TTL = m.ttl(mapping.TTL)
+		mapping.ModificationTag = models.ModificationTag{}
+		mappings = append(mappings, mapping)
+	}
+
+	if len(mappings) > 0 {
+		groups, err := ListRouterGroups(from)
+		if err != nil {
+			return Plan{}, err
+		}
+		mappings, err = remapRouterGroups(to, groups, mappings)
+		if err != nil {
+			return Plan{}, err
+		}
+	}
+
+	return MakePlan(to, routes, mappings, Scope{}, false)
+}
+
+func (m Migration) rewrite(ip string) string {
+	for _, rule := range m.Rewrites {
+		if rewritten, ok := rule.Rewrite(ip); ok {
+			return rewritten
+		}
+	}
+	return ip
+}
+
+func (m Migration) ttl(ttl *int) *int {
+	if m.TTLOverride <= 0 {
+		return ttl
+	}
+	override := m.TTLOverride
+	return &override
+}
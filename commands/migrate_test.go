@@ -0,0 +1,112 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrate", func() {
+	Describe(".ParseRewriteRule", func() {
+		It("moves IPs into the other network, keeping their host part", func() {
+			rule, err := commands.ParseRewriteRule("10.0.0.0/16=10.1.0.0/16")
+			Expect(err).NotTo(HaveOccurred())
+
+			ip, ok := rule.Rewrite("10.0.1.5")
+			Expect(ok).To(BeTrue())
+			Expect(ip).To(Equal("10.1.1.5"))
+
+			ip, ok = rule.Rewrite("192.168.0.1")
+			Expect(ok).To(BeFalse())
+			Expect(ip).To(Equal("192.168.0.1"))
+		})
+
+		It("rejects malformed rules", func() {
+			_, err := commands.ParseRewriteRule("10.0.0.0/16")
+			Expect(err).To(MatchError(`"10.0.0.0/16" is not written as <from CIDR>=<to CIDR>`))
+
+			_, err = commands.ParseRewriteRule("10.0.0.0/16=nope")
+			Expect(err).To(HaveOccurred())
+
+			_, err = commands.ParseRewriteRule("10.0.0.0/16=10.1.0.0/24")
+			Expect(err).To(MatchError("10.0.0.0/16 and 10.1.0.0/24 differ in size"))
+		})
+	})
+
+	Describe("Migration.Plan", func() {
+		var (
+			from, to  *fake_routing_api.FakeClient
+			migration commands.Migration
+		)
+
+		BeforeEach(func() {
+			from = &fake_routing_api.FakeClient{}
+			to = &fake_routing_api.FakeClient{}
+
+			route := models.NewRoute("a.example.com", 8080, "10.0.1.5", "team-a", "", 60)
+			route.ModificationTag = models.ModificationTag{Guid: "tag", Index: 3}
+			from.RoutesReturns([]models.Route{
+				route,
+				models.NewRoute("b.example.com", 8080, "10.0.1.6", "team-b", "", 60),
+			}, nil)
+			from.TcpRouteMappingsReturns([]models.TcpRouteMapping{
+				models.NewTcpRouteMapping("old-guid", 1024, "10.0.1.7", 61000, 0, "", nil, 60, models.ModificationTag{}),
+			}, nil)
+			from.RouterGroupsReturns([]models.RouterGroup{{Guid: "old-guid", Name: "default-tcp"}}, nil)
+
+			to.RoutesReturns([]models.Route{
+				models.NewRoute("b.example.com", 8080, "10.1.1.6", "team-b", "", 60),
+			}, nil)
+			to.RouterGroupsReturns([]models.RouterGroup{{Guid: "new-guid", Name: "default-tcp"}}, nil)
+
+			rule, err := commands.ParseRewriteRule("10.0.0.0/16=10.1.0.0/16")
+			Expect(err).NotTo(HaveOccurred())
+			migration = commands.Migration{Rewrites: []commands.RewriteRule{rule}}
+		})
+
+		It("compares the rewritten routes with the ones of the destination", func() {
+			plan, err := migration.Plan(from, to)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plan.Routes).To(HaveLen(2))
+			Expect(plan.Routes[0].Action).To(Equal(commands.ChangeCreate))
+			Expect(plan.Routes[0].Route.IP).To(Equal("10.1.1.5"))
+			Expect(plan.Routes[0].Route.ModificationTag).To(Equal(models.ModificationTag{}))
+			Expect(plan.Routes[1].Action).To(Equal(commands.ChangeUnchanged))
+
+			Expect(plan.TcpRoutes).To(HaveLen(1))
+			Expect(plan.TcpRoutes[0].Action).To(Equal(commands.ChangeCreate))
+			Expect(plan.TcpRoutes[0].Mapping.RouterGroupGuid).To(Equal("new-guid"))
+			Expect(plan.TcpRoutes[0].Mapping.HostIP).To(Equal("10.1.1.7"))
+		})
+
+		It("copies only the routes in scope", func() {
+			migration.Scope = commands.Scope{LogGuids: []string{"team-a"}}
+
+			plan, err := migration.Plan(from, to)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Routes).To(HaveLen(1))
+			Expect(plan.Routes[0].Route.Route).To(Equal("a.example.com"))
+			Expect(plan.TcpRoutes).To(BeEmpty())
+		})
+
+		It("replaces the TTL of the copied routes with the override", func() {
+			migration.TTLOverride = 3600
+
+			plan, err := migration.Plan(from, to)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*plan.Routes[0].Route.TTL).To(Equal(3600))
+			Expect(plan.Routes[1].Action).To(Equal(commands.ChangeUpdate))
+			Expect(*plan.TcpRoutes[0].Mapping.TTL).To(Equal(3600))
+		})
+
+		It("fails when the destination lacks a router group", func() {
+			to.RouterGroupsReturns(nil, nil)
+
+			_, err := migration.Plan(from, to)
+			Expect(err).To(MatchError(ContainSubstring("router group default-tcp does not exist")))
+		})
+	})
+})
@@ -0,0 +1,211 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// Scope selects the registered routes a plan manages, so that pruning never
+// touches the routes of others. HTTP routes are in scope when their log guid
+// or hostname matches, TCP route mappings when their SNI hostname or router
+// group does.
+type Scope struct {
+	LogGuids         []string
+	Routes           []*regexp.Regexp
+	RouterGroupGuids []string
+}
+
+// Empty reports whether no scope was given.
+func (s Scope) Empty() bool {
+	return len(s.LogGuids) == 0 && len(s.Routes) == 0 && len(s.RouterGroupGuids) == 0
+}
+
+// ContainsRoute reports whether the HTTP route is in scope.
+func (s Scope) ContainsRoute(route models.Route) bool {
+	return containsString(s.LogGuids, route.LogGuid) || matchAny(s.Routes, route.Route)
+}
+
+// ContainsTcpRoute reports whether the TCP route mapping is in scope.
+func (s Scope) ContainsTcpRoute(mapping models.TcpRouteMapping) bool {
+	return containsString(s.RouterGroupGuids, mapping.RouterGroupGuid) || matchSniHostname(s.Routes, mapping)
+}
+
+// TcpRouteChange is what applying a plan does to Mapping. Current is the
+// registered TCP route mapping with the same frontend and backend, if any.
+type TcpRouteChange struct {
+	Action  string                  `json:"action"`
+	Mapping models.TcpRouteMapping  `json:"mapping"`
+	Current *models.TcpRouteMapping `json:"current,omitempty"`
+}
+
+// Plan lists the changes that make the registered routes match the desired
+// ones, including the routes left unchanged.
+type Plan struct {
+	Routes    []RouteChange    `json:"routes"`
+	TcpRoutes []TcpRouteChange `json:"tcp_routes"`
+}
+
+// Counts counts the changes of HTTP routes and TCP route mappings by action.
+func (p Plan) Counts() map[string]int {
+	counts := CountChanges(p.Routes)
+	for _, change := range p.TcpRoutes {
+		counts[change.Action]++
+	}
+	return counts
+}
+
+// MakePlan compares the desired routes and TCP route mappings with the
+// registered ones. Desired routes are created, or updated when their TTL or
+// route service differs. With prune, the registered routes in scope that are
+// not desired are deleted, which requires a scope. Desired routes have to be
+// in scope when one is given.
+func MakePlan(client routing_api.Client, routes []models.Route, tcpRoutes []models.TcpRouteMapping, scope Scope, prune bool) (Plan, error) {
+	var plan Plan
+
+	if prune && scope.Empty() {
+		return plan, fmt.Errorf("pruning requires a scope")
+	}
+	if err := checkScope(routes, tcpRoutes, scope); err != nil {
+		return plan, err
+	}
+
+	registeredRoutes, err := List(client)
+	if err != nil {
+		return plan, err
+	}
+	plan.Routes = planRoutes(routes, registeredRoutes)
+
+	registeredTcpRoutes, err := ListTcp(client, nil)
+	if err != nil {
+		return plan, err
+	}
+	plan.TcpRoutes = planTcpRoutes(tcpRoutes, registeredTcpRoutes)
+
+	if !prune {
+		return plan, nil
+	}
+
+	desired := map[string]bool{}
+	for _, route := range routes {
+		desired[routeKey(route)] = true
+	}
+	for _, route := range registeredRoutes {
+		if scope.ContainsRoute(route) && !desired[routeKey(route)] {
+			current := route
+			plan.Routes = append(plan.Routes, RouteChange{Action: ChangeDelete, Route: route, Current: &current})
+		}
+	}
+
+	desired = map[string]bool{}
+	for _, mapping := range tcpRoutes {
+		desired[tcpRouteKey(mapping)] = true
+	}
+	for _, mapping := range registeredTcpRoutes {
+		if scope.ContainsTcpRoute(mapping) && !desired[tcpRouteKey(mapping)] {
+			current := mapping
+			plan.TcpRoutes = append(plan.TcpRoutes, TcpRouteChange{Action: ChangeDelete, Mapping: mapping, Current: &current})
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply registers every desired route of the plan and unregisters the
+// deleted ones. Unchanged routes are registered again as well, since
+// registering is what keeps routes with a TTL from expiring.
+func Apply(client routing_api.Client, plan Plan) error {
+	var upserts, deletes []models.Route
+	for _, change := range plan.Routes {
+		switch change.Action {
+		case ChangeCreate, ChangeUpdate, ChangeUnchanged:
+			upserts = append(upserts, change.Route)
+		case ChangeDelete:
+			deletes = append(deletes, change.Route)
+		}
+	}
+
+	var tcpUpserts, tcpDeletes []models.TcpRouteMapping
+	for _, change := range plan.TcpRoutes {
+		switch change.Action {
+		case ChangeCreate, ChangeUpdate, ChangeUnchanged:
+			tcpUpserts = append(tcpUpserts, change.Mapping)
+		case ChangeDelete:
+			tcpDeletes = append(tcpDeletes, change.Mapping)
+		}
+	}
+
+	if len(upserts) > 0 {
+		if err := Register(client, upserts); err != nil {
+			return err
+		}
+	}
+	if len(deletes) > 0 {
+		if err := UnRegister(client, deletes); err != nil {
+			return err
+		}
+	}
+	if len(tcpUpserts) > 0 {
+		if err := RegisterTcp(client, tcpUpserts); err != nil {
+			return err
+		}
+	}
+	if len(tcpDeletes) > 0 {
+		if err := UnregisterTcp(client, tcpDeletes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkScope(routes []models.Route, tcpRoutes []models.TcpRouteMapping, scope Scope) error {
+	if scope.Empty() {
+		return nil
+	}
+
+	for _, route := range routes {
+		if !scope.ContainsRoute(route) {
+			return fmt.Errorf("desired route %s is outside of the scope", routeKey(route))
+		}
+	}
+	for _, mapping := range tcpRoutes {
+		if !scope.ContainsTcpRoute(mapping) {
+			return fmt.Errorf("desired tcp route %s is outside of the scope", tcpRouteKey(mapping))
+		}
+	}
+	return nil
+}
+
+func planTcpRoutes(mappings, registered []models.TcpRouteMapping) []TcpRouteChange {
+	current := make(map[string]models.TcpRouteMapping, len(registered))
+	for _, mapping := range registered {
+		current[tcpRouteKey(mapping)] = mapping
+	}
+
+	changes := make([]TcpRouteChange, 0, len(mappings))
+	for _, mapping := range mappings {
+		change := TcpRouteChange{Action: ChangeCreate, Mapping: mapping}
+		if registered, found := current[tcpRouteKey(mapping)]; found {
+			change.Current = &registered
+			change.Action = ChangeUnchanged
+			if !sameTTL(mapping.TTL, registered.TTL) || mapping.IsolationSegment != registered.IsolationSegment {
+				change.Action = ChangeUpdate
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// tcpRouteKey identifies a TCP route mapping by its frontend and backend.
+func tcpRouteKey(mapping models.TcpRouteMapping) string {
+	sniHostname := ""
+	if mapping.SniHostname != nil {
+		sniHostname = *mapping.SniHostname
+	}
+	return mapping.RouterGroupGuid + ":" + strconv.Itoa(int(mapping.ExternalPort)) + " " +
+		mapping.HostIP + ":" + strconv.Itoa(int(mapping.HostPort)) + " " + sniHostname
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+)
+
+// PlanFileVersion is the version of the plan files written by WritePlan.
+const PlanFileVersion = 1
+
+// SavedPlan is a plan written to a file, to be applied after it was
+// reviewed.
+type SavedPlan struct {
+	Version   int       `json:"version"`
+	API       string    `json:"api"`
+	CreatedAt time.Time `json:"created_at"`
+	Plan      Plan      `json:"plan"`
+}
+
+// WritePlan writes the plan as gzipped JSON.
+func WritePlan(w io.Writer, plan SavedPlan) error {
+	plan.Version = PlanFileVersion
+
+	gz := gzip.NewWriter(w)
+	err := json.NewEncoder(gz).Encode(plan)
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadPlan reads a plan written by WritePlan.
+func ReadPlan(r io.Reader) (SavedPlan, error) {
+	var plan SavedPlan
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return plan, fmt.Errorf("not a plan file: %s", err)
+	}
+	defer gz.Close()
+
+	err = json.NewDecoder(gz).Decode(&plan)
+	if err != nil {
+		return plan, fmt.Errorf("not a plan file: %s", err)
+	}
+	if plan.Version != PlanFileVersion {
+		return plan, fmt.Errorf("unsupported plan file version %d", plan.Version)
+	}
+	return plan, nil
+}
+
+// DriftError lists the routes that changed since a plan was made.
+type DriftError struct {
+	Drifts []string
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("the routes changed since the plan was made:\n  %s", strings.Join(e.Drifts, "\n  "))
+}
+
+// CheckDrift compares the routes a plan changes with the registered ones,
+// and returns a DriftError when any of them was registered, unregistered or
+// modified since, as told by its modification tag. Unchanged routes are not
+// checked, since applying the plan only registers them again as desired.
+func CheckDrift(client routing_api.Client, plan Plan) error {
+	routes, err := List(client)
+	if err != nil {
+		return err
+	}
+	current := routesByKey(routes)
+
+	var drifts []string
+	for _, change := range plan.Routes {
+		if change.Action == ChangeUnchanged {
+			continue
+		}
+		key := routeKey(change.Route)
+		registered, found := current[key]
+		if drift := describeDrift(key, change.Current != nil, found, func() bool {
+			return registered.ModificationTag != change.Current.ModificationTag
+		}); drift != "" {
+			drifts = append(drifts, "route "+drift)
+		}
+	}
+
+	mappings, err := ListTcp(client, nil)
+	if err != nil {
+		return err
+	}
+	currentTcp := map[string]int{}
+	for i, mapping := range mappings {
+		currentTcp[tcpRouteKey(mapping)] = i
+	}
+
+	for _, change := range plan.TcpRoutes {
+		if change.Action == ChangeUnchanged {
+			continue
+		}
+		key := tcpRouteKey(change.Mapping)
+		i, found := currentTcp[key]
+		if drift := describeDrift(key, change.Current != nil, found, func() bool {
+			return mappings[i].ModificationTag != change.Current.ModificationTag
+		}); drift != "" {
+			drifts = append(drifts, "tcp route "+drift)
+		}
+	}
+
+	if len(drifts) > 0 {
+		return &DriftError{Drifts: drifts}
+	}
+	return nil
+}
+
+func describeDrift(key string, planned, found bool, modified func() bool) string {
+	key = strings.TrimSpace(key)
+	switch {
+	case !planned && found:
+		return key + " was registered"
+	case planned && !found:
+		return key + " was unregistered"
+	case planned && modified():
+		return key + " was modified"
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package commands_test
+
+import (
+	"bytes"
+	"time"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PlanFile", func() {
+	var (
+		client     *fake_routing_api.FakeClient
+		registered []models.Route
+		plan       commands.Plan
+	)
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+		registered = []models.Route{
+			models.NewRoute("a.example.com", 8080, "10.0.0.1", "team-a", "", 60),
+			models.NewRoute("old.example.com", 8080, "10.0.0.1", "team-a", "", 60),
+		}
+		registered[0].ModificationTag = models.ModificationTag{Guid: "guid-a", Index: 3}
+		client.RoutesReturns(registered, nil)
+		client.TcpRouteMappingsReturns([]models.TcpRouteMapping{
+			models.NewTcpRouteMapping("group-a", 1024, "10.0.0.1", 61000, 0, "", nil, 60, models.ModificationTag{Guid: "guid-t", Index: 1}),
+		}, nil)
+
+		var err error
+		plan, err = commands.MakePlan(client, []models.Route{
+			models.NewRoute("a.example.com", 8080, "10.0.0.1", "team-a", "", 120),
+			models.NewRoute("new.example.com", 8080, "10.0.0.1", "team-a", "", 60),
+		}, []models.TcpRouteMapping{
+			models.NewTcpRouteMapping("group-a", 1024, "10.0.0.1", 61000, 0, "", nil, 120, models.ModificationTag{}),
+		}, commands.Scope{LogGuids: []string{"team-a"}}, true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("reads the plan it wrote", func() {
+		saved := commands.SavedPlan{API: "https://api.example.com", CreatedAt: time.Unix(1700000000, 0).UTC(), Plan: plan}
+
+		var buf bytes.Buffer
+		Expect(commands.WritePlan(&buf, saved)).To(Succeed())
+
+		read, err := commands.ReadPlan(&buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read.Version).To(Equal(commands.PlanFileVersion))
+		Expect(read.API).To(Equal(saved.API))
+		Expect(read.CreatedAt).To(Equal(saved.CreatedAt))
+		Expect(read.Plan).To(Equal(plan))
+	})
+
+	It("rejects files that are not plans", func() {
+		_, err := commands.ReadPlan(bytes.NewBufferString(`{"routes": []}`))
+		Expect(err).To(MatchError(HavePrefix("not a plan file:")))
+	})
+
+	It("finds no drift when the routes did not change", func() {
+		Expect(commands.CheckDrift(client, plan)).To(Succeed())
+	})
+
+	It("ignores modified routes the plan leaves unchanged", func() {
+		var err error
+		plan, err = commands.MakePlan(client, registered[:1], nil, commands.Scope{LogGuids: []string{"team-a"}}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Routes).To(HaveLen(1))
+		Expect(plan.Routes[0].Action).To(Equal(commands.ChangeUnchanged))
+
+		modified := registered[0]
+		modified.ModificationTag.Index = 4
+		client.RoutesReturns([]models.Route{modified, registered[1]}, nil)
+
+		Expect(commands.CheckDrift(client, plan)).To(Succeed())
+	})
+
+	It("reports the routes that were modified, registered or unregistered since", func() {
+		modified := registered[0]
+		modified.ModificationTag.Index = 4
+		client.RoutesReturns([]models.Route{
+			modified,
+			models.NewRoute("new.example.com", 8080, "10.0.0.1", "team-b", "", 60),
+		}, nil)
+		client.TcpRouteMappingsReturns(nil, nil)
+
+		err := commands.CheckDrift(client, plan)
+		Expect(err).To(BeAssignableToTypeOf(&commands.DriftError{}))
+		Expect(err.(*commands.DriftError).Drifts).To(Equal([]string{
+			"route a.example.com 10.0.0.1:8080 was modified",
+			"route new.example.com 10.0.0.1:8080 was registered",
+			"route old.example.com 10.0.0.1:8080 was unregistered",
+			"tcp route group-a:1024 10.0.0.1:61000 was unregistered",
+		}))
+	})
+})
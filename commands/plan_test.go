@@ -0,0 +1,138 @@
+package commands_test
+
+import (
+	"regexp"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Plan", func() {
+	var (
+		client    *fake_routing_api.FakeClient
+		routes    []models.Route
+		tcpRoutes []models.TcpRouteMapping
+		scope     commands.Scope
+	)
+
+	actions := func(plan commands.Plan) []string {
+		result := []string{}
+		for _, change := range plan.Routes {
+			result = append(result, change.Action+" "+change.Route.Route)
+		}
+		for _, change := range plan.TcpRoutes {
+			result = append(result, change.Action+" tcp "+change.Mapping.HostIP)
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+		client.RoutesReturns([]models.Route{
+			models.NewRoute("a.example.com", 8080, "10.0.0.1", "team-a", "", 60),
+			models.NewRoute("old.example.com", 8080, "10.0.0.1", "team-a", "", 60),
+			models.NewRoute("other.example.org", 8080, "10.0.0.1", "team-b", "", 60),
+		}, nil)
+		client.TcpRouteMappingsReturns([]models.TcpRouteMapping{
+			models.NewTcpRouteMapping("group-a", 1024, "10.0.0.1", 61000, 0, "", nil, 60, models.ModificationTag{}),
+			models.NewTcpRouteMapping("group-b", 1025, "10.0.0.2", 61000, 0, "", nil, 60, models.ModificationTag{}),
+		}, nil)
+
+		routes = []models.Route{
+			models.NewRoute("a.example.com", 8080, "10.0.0.1", "team-a", "", 120),
+			models.NewRoute("new.example.com", 8080, "10.0.0.1", "team-a", "", 60),
+		}
+		tcpRoutes = []models.TcpRouteMapping{
+			models.NewTcpRouteMapping("group-a", 1024, "10.0.0.3", 61000, 0, "", nil, 60, models.ModificationTag{}),
+		}
+		scope = commands.Scope{LogGuids: []string{"team-a"}, RouterGroupGuids: []string{"group-a"}}
+	})
+
+	It("creates and updates the desired routes", func() {
+		plan, err := commands.MakePlan(client, routes, tcpRoutes, scope, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions(plan)).To(Equal([]string{
+			"update a.example.com",
+			"create new.example.com",
+			"create tcp 10.0.0.3",
+		}))
+		Expect(client.RoutesCallCount()).To(Equal(1))
+	})
+
+	It("deletes the routes in scope that are not desired when pruning", func() {
+		plan, err := commands.MakePlan(client, routes, tcpRoutes, scope, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions(plan)).To(Equal([]string{
+			"update a.example.com",
+			"create new.example.com",
+			"delete old.example.com",
+			"create tcp 10.0.0.3",
+			"delete tcp 10.0.0.1",
+		}))
+		Expect(plan.Counts()).To(Equal(map[string]int{
+			commands.ChangeUpdate: 1,
+			commands.ChangeCreate: 2,
+			commands.ChangeDelete: 2,
+		}))
+	})
+
+	It("scopes by hostname", func() {
+		scope = commands.Scope{Routes: []*regexp.Regexp{commands.Glob("*.example.com")}}
+		plan, err := commands.MakePlan(client, routes, nil, scope, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions(plan)).To(ContainElement("delete old.example.com"))
+		Expect(actions(plan)).NotTo(ContainElement("delete other.example.org"))
+		Expect(plan.TcpRoutes).To(BeEmpty())
+	})
+
+	It("requires a scope for pruning", func() {
+		_, err := commands.MakePlan(client, routes, tcpRoutes, commands.Scope{}, true)
+		Expect(err).To(MatchError("pruning requires a scope"))
+	})
+
+	It("rejects desired routes outside of the scope", func() {
+		routes = append(routes, models.NewRoute("b.example.com", 8080, "10.0.0.1", "team-b", "", 60))
+		_, err := commands.MakePlan(client, routes, tcpRoutes, scope, false)
+		Expect(err).To(MatchError("desired route b.example.com 10.0.0.1:8080 is outside of the scope"))
+	})
+
+	It("applies the changes", func() {
+		plan, err := commands.MakePlan(client, routes, tcpRoutes, scope, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commands.Apply(client, plan)).To(Succeed())
+
+		Expect(client.UpsertRoutesCallCount()).To(Equal(1))
+		Expect(client.UpsertRoutesArgsForCall(0)).To(Equal(routes))
+		Expect(client.DeleteRoutesCallCount()).To(Equal(1))
+		Expect(client.DeleteRoutesArgsForCall(0)[0].Route).To(Equal("old.example.com"))
+		Expect(client.UpsertTcpRouteMappingsCallCount()).To(Equal(1))
+		Expect(client.DeleteTcpRouteMappingsCallCount()).To(Equal(1))
+		Expect(client.DeleteTcpRouteMappingsArgsForCall(0)[0].HostIP).To(Equal("10.0.0.1"))
+	})
+
+	It("registers unchanged routes again to refresh their TTL", func() {
+		routes = []models.Route{models.NewRoute("a.example.com", 8080, "10.0.0.1", "team-a", "", 60)}
+		tcpRoutes = []models.TcpRouteMapping{
+			models.NewTcpRouteMapping("group-a", 1024, "10.0.0.1", 61000, 0, "", nil, 60, models.ModificationTag{}),
+		}
+		plan, err := commands.MakePlan(client, routes, tcpRoutes, scope, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions(plan)).To(Equal([]string{"unchanged a.example.com", "unchanged tcp 10.0.0.1"}))
+
+		Expect(commands.Apply(client, plan)).To(Succeed())
+		Expect(client.UpsertRoutesCallCount()).To(Equal(1))
+		Expect(client.UpsertRoutesArgsForCall(0)).To(Equal(routes))
+		Expect(client.UpsertTcpRouteMappingsCallCount()).To(Equal(1))
+		Expect(client.UpsertTcpRouteMappingsArgsForCall(0)).To(Equal(tcpRoutes))
+		Expect(client.DeleteRoutesCallCount()).To(Equal(0))
+	})
+
+	It("does not send anything for an empty plan", func() {
+		Expect(commands.Apply(client, commands.Plan{})).To(Succeed())
+		Expect(client.UpsertRoutesCallCount()).To(Equal(0))
+		Expect(client.DeleteRoutesCallCount()).To(Equal(0))
+	})
+})
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// maximumRouteLineSize bounds the lines a RouteStream reads.
+const maximumRouteLineSize = 1024 * 1024
+
+// RouteStream registers newline-delimited JSON routes as they are read, in
+// batches of up to BatchSize routes. A batch that is not full is registered
+// FlushInterval after its first route was read.
+type RouteStream struct {
+	Client        routing_api.Client
+	BatchSize     int
+	FlushInterval time.Duration
+	// Registered is called after every batch with its number, starting at
+	// 1, its routes and the error registering them, if set.
+	Registered func(batch int, routes []models.Route, err error)
+	// Invalid is called with the lines that are not a route, if set.
+	Invalid func(line int, err error)
+	// Validate checks every route like ValidateRegister, treating the lines
+	// of invalid routes as invalid.
+	Validate bool
+	Clock    clock.Clock
+}
+
+// RouteStreamSummary counts the routes a RouteStream registered.
+type RouteStreamSummary struct {
+	Batches    int
+	Registered int
+	Failed     int
+	Invalid    int
+}
+
+type routeLine struct {
+	number int
+	route  models.Route
+	err    error
+}
+
+// Run registers the routes read from r until it ends, and returns the error
+// reading it failed with.
+func (s RouteStream) Run(r io.Reader) (RouteStreamSummary, error) {
+	var summary RouteStreamSummary
+	lines := make(chan routeLine)
+	readErr := make(chan error, 1)
+	go readRouteLines(r, s.Validate, lines, readErr)
+
+	var batch []models.Route
+	var timer clock.Timer
+	var flush <-chan time.Time
+
+	register := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, flush = nil, nil
+		}
+		if len(batch) == 0 {
+			return
+		}
+
+		summary.Batches++
+		err := Register(s.Client, batch)
+		if err != nil {
+			summary.Failed += len(batch)
+		} else {
+			summary.Registered += len(batch)
+		}
+		if s.Registered != nil {
+			s.Registered(summary.Batches, batch, err)
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				register()
+				return summary, <-readErr
+			}
+			if line.err != nil {
+				summary.Invalid++
+				if s.Invalid != nil {
+					s.Invalid(line.number, line.err)
+				}
+				continue
+			}
+
+			batch = append(batch, line.route)
+			if len(batch) >= s.BatchSize {
+				register()
+			} else if timer == nil {
+				timer = s.Clock.NewTimer(s.FlushInterval)
+				flush = timer.C()
+			}
+		case <-flush:
+			timer, flush = nil, nil
+			register()
+		}
+	}
+}
+
+// readRouteLines decodes every non-empty line of r as a route, and closes
+// lines once r ends.
+func readRouteLines(r io.Reader, validate bool, lines chan<- routeLine, readErr chan<- error) {
+	defer close(lines)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maximumRouteLineSize)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var route models.Route
+		err := json.Unmarshal([]byte(text), &route)
+		if err == nil && validate {
+			if problems := routeProblems(route, true); len(problems) > 0 {
+				err = errors.New(strings.Join(problems, ", "))
+			}
+		}
+		lines <- routeLine{number: number, route: route, err: err}
+	}
+	readErr <- scanner.Err()
+}
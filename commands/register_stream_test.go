@@ -0,0 +1,94 @@
+package commands_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RouteStream", func() {
+	var (
+		client  *fake_routing_api.FakeClient
+		clock   *fakeclock.FakeClock
+		stream  commands.RouteStream
+		batches chan []models.Route
+	)
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+		clock = fakeclock.NewFakeClock(time.Now())
+		batches = make(chan []models.Route, 10)
+		stream = commands.RouteStream{
+			Client:        client,
+			BatchSize:     2,
+			FlushInterval: time.Second,
+			Registered: func(batch int, routes []models.Route, err error) {
+				batches <- routes
+			},
+			Clock: clock,
+		}
+	})
+
+	It("registers the routes in batches and summarizes them", func() {
+		client.UpsertRoutesReturnsOnCall(1, errors.New("boom"))
+		invalid := []int{}
+		stream.Invalid = func(line int, err error) { invalid = append(invalid, line) }
+
+		summary, err := stream.Run(strings.NewReader(`{"route":"a.com"}
+{"route":"b.com"}
+
+{"route":"c.com"}
+not json
+{"route":"d.com"}
+{"route":"e.com"}
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary).To(Equal(commands.RouteStreamSummary{Batches: 3, Registered: 3, Failed: 2, Invalid: 1}))
+		Expect(invalid).To(Equal([]int{5}))
+
+		Expect(client.UpsertRoutesCallCount()).To(Equal(3))
+		Expect(client.UpsertRoutesArgsForCall(0)).To(HaveLen(2))
+		Expect(client.UpsertRoutesArgsForCall(1)[0].Route).To(Equal("c.com"))
+		Expect(client.UpsertRoutesArgsForCall(2)).To(HaveLen(1))
+		Expect(batches).To(HaveLen(3))
+	})
+
+	It("registers a batch that is not full after the flush interval", func() {
+		reader, writer := io.Pipe()
+		done := make(chan commands.RouteStreamSummary)
+		go func() {
+			summary, _ := stream.Run(reader)
+			done <- summary
+		}()
+
+		_, err := writer.Write([]byte(`{"route":"a.com"}` + "\n"))
+		Expect(err).NotTo(HaveOccurred())
+		clock.WaitForWatcherAndIncrement(time.Second)
+
+		Eventually(batches).Should(Receive(HaveLen(1)))
+		Expect(client.UpsertRoutesCallCount()).To(Equal(1))
+
+		Expect(writer.Close()).To(Succeed())
+		Eventually(done).Should(Receive(Equal(commands.RouteStreamSummary{Batches: 1, Registered: 1})))
+	})
+
+	It("returns the error reading the routes", func() {
+		_, err := stream.Run(io.MultiReader(strings.NewReader(`{"route":"a.com"}`+"\n"), errorReader{}))
+		Expect(err).To(MatchError("read failed"))
+		Expect(client.UpsertRoutesCallCount()).To(Equal(1))
+	})
+})
+
+type errorReader struct{}
+
+func (errorReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
@@ -0,0 +1,10 @@
+package commands
+
+import (
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+func RegisterTcp(client routing_api.Client, mappings []models.TcpRouteMapping) error {
+	return client.UpsertTcpRouteMappings(mappings)
+}
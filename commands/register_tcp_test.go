@@ -0,0 +1,27 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(".RegisterTcp", func() {
+	var (
+		client *fake_routing_api.FakeClient
+	)
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+	})
+
+	It("registers tcp route mappings", func() {
+		mappings := []models.TcpRouteMapping{{}}
+		commands.RegisterTcp(client, mappings)
+		Expect(client.UpsertTcpRouteMappingsCallCount()).To(Equal(1))
+		Expect(client.UpsertTcpRouteMappingsArgsForCall(0)).To(Equal(mappings))
+	})
+
+})
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+type portRange struct {
+	start, end uint64
+}
+
+func ListRouterGroups(client routing_api.Client) ([]models.RouterGroup, error) {
+	return client.RouterGroups()
+}
+
+func GetRouterGroup(client routing_api.Client, name string) (models.RouterGroup, error) {
+	return client.RouterGroupWithName(name)
+}
+
+func CreateRouterGroup(client routing_api.Client, group models.RouterGroup) error {
+	err := ValidateRouterGroup(group)
+	if err != nil {
+		return err
+	}
+	return client.CreateRouterGroup(group)
+}
+
+// UpdateRouterGroup updates the router group identified by its guid, looking
+// the guid up by name when it is not given.
+func UpdateRouterGroup(client routing_api.Client, group models.RouterGroup) error {
+	err := ValidateRouterGroup(group)
+	if err != nil {
+		return err
+	}
+
+	if group.Guid == "" {
+		existing, err := client.RouterGroupWithName(group.Name)
+		if err != nil {
+			return err
+		}
+		group.Guid = existing.Guid
+	}
+	return client.UpdateRouterGroup(group)
+}
+
+func DeleteRouterGroup(client routing_api.Client, name string) error {
+	group, err := client.RouterGroupWithName(name)
+	if err != nil {
+		return err
+	}
+	return client.DeleteRouterGroup(group)
+}
+
+func ValidateRouterGroup(group models.RouterGroup) error {
+	if group.Name == "" {
+		return fmt.Errorf("router group name must not be empty")
+	}
+
+	switch group.Type {
+	case models.RouterGroup_TCP:
+		if group.ReservablePorts == "" {
+			return fmt.Errorf("tcp router group %s must have reservable_ports", group.Name)
+		}
+	case models.RouterGroup_HTTP:
+		if group.ReservablePorts == "" {
+			return nil
+		}
+	default:
+		return fmt.Errorf("router group type must be %q or %q, got %q", models.RouterGroup_TCP, models.RouterGroup_HTTP, group.Type)
+	}
+
+	return ValidateReservablePorts(group.ReservablePorts)
+}
+
+// ValidateReservablePorts checks that ports is a comma separated list of
+// ports and port ranges, e.g. "1024-1033,2000", without overlapping entries.
+func ValidateReservablePorts(ports models.ReservablePorts) error {
+	var ranges []portRange
+
+	for _, entry := range strings.Split(string(ports), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			return fmt.Errorf("invalid reservable_ports %q: empty port range", ports)
+		}
+
+		bounds := strings.Split(entry, "-")
+		if len(bounds) > 2 {
+			return fmt.Errorf("invalid reservable_ports %q: %q is not a port or port range", ports, entry)
+		}
+
+		var r portRange
+		var err error
+		r.start, err = parsePort(bounds[0])
+		if err != nil {
+			return fmt.Errorf("invalid reservable_ports %q: %s", ports, err)
+		}
+		r.end = r.start
+		if len(bounds) == 2 {
+			r.end, err = parsePort(bounds[1])
+			if err != nil {
+				return fmt.Errorf("invalid reservable_ports %q: %s", ports, err)
+			}
+		}
+
+		if r.start > r.end {
+			return fmt.Errorf("invalid reservable_ports %q: range %q must be ascending", ports, entry)
+		}
+		ranges = append(ranges, r)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].start <= ranges[i-1].end {
+			return fmt.Errorf("invalid reservable_ports %q: port ranges overlap", ports)
+		}
+	}
+
+	return nil
+}
+
+func parsePort(s string) (uint64, error) {
+	port, err := strconv.ParseUint(strings.TrimSpace(s), 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("%q is not a valid port", s)
+	}
+	return port, nil
+}
@@ -0,0 +1,143 @@
+package commands_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Router groups", func() {
+	var (
+		client *fake_routing_api.FakeClient
+		group  models.RouterGroup
+	)
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+		group = models.RouterGroup{
+			Guid:            "some-guid",
+			Name:            "default-tcp",
+			Type:            models.RouterGroup_TCP,
+			ReservablePorts: "1024-1033",
+		}
+	})
+
+	Describe(".ListRouterGroups", func() {
+		It("lists router groups", func() {
+			client.RouterGroupsReturns([]models.RouterGroup{group}, nil)
+			groups, err := commands.ListRouterGroups(client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.RouterGroupsCallCount()).To(Equal(1))
+			Expect(groups).To(Equal([]models.RouterGroup{group}))
+		})
+	})
+
+	Describe(".GetRouterGroup", func() {
+		It("gets the router group by name", func() {
+			client.RouterGroupWithNameReturns(group, nil)
+			found, err := commands.GetRouterGroup(client, "default-tcp")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.RouterGroupWithNameArgsForCall(0)).To(Equal("default-tcp"))
+			Expect(found).To(Equal(group))
+		})
+	})
+
+	Describe(".CreateRouterGroup", func() {
+		It("creates the router group", func() {
+			err := commands.CreateRouterGroup(client, group)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.CreateRouterGroupCallCount()).To(Equal(1))
+			Expect(client.CreateRouterGroupArgsForCall(0)).To(Equal(group))
+		})
+
+		It("does not send router groups with invalid reservable ports", func() {
+			group.ReservablePorts = "1024-abc"
+			err := commands.CreateRouterGroup(client, group)
+			Expect(err).To(MatchError(ContainSubstring(`"abc" is not a valid port`)))
+			Expect(client.CreateRouterGroupCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe(".UpdateRouterGroup", func() {
+		It("looks up the guid by name when it is missing", func() {
+			client.RouterGroupWithNameReturns(group, nil)
+			update := group
+			update.Guid = ""
+			update.ReservablePorts = "2000-3000"
+
+			err := commands.UpdateRouterGroup(client, update)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.RouterGroupWithNameArgsForCall(0)).To(Equal("default-tcp"))
+			Expect(client.UpdateRouterGroupCallCount()).To(Equal(1))
+
+			updated := client.UpdateRouterGroupArgsForCall(0)
+			Expect(updated.Guid).To(Equal("some-guid"))
+			Expect(updated.ReservablePorts).To(Equal(models.ReservablePorts("2000-3000")))
+		})
+
+		It("returns the lookup error", func() {
+			client.RouterGroupWithNameReturns(models.RouterGroup{}, errors.New("not found"))
+			group.Guid = ""
+			err := commands.UpdateRouterGroup(client, group)
+			Expect(err).To(MatchError("not found"))
+			Expect(client.UpdateRouterGroupCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe(".DeleteRouterGroup", func() {
+		It("deletes the router group with the given name", func() {
+			client.RouterGroupWithNameReturns(group, nil)
+			err := commands.DeleteRouterGroup(client, "default-tcp")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.DeleteRouterGroupCallCount()).To(Equal(1))
+			Expect(client.DeleteRouterGroupArgsForCall(0)).To(Equal(group))
+		})
+	})
+
+	Describe(".ValidateReservablePorts", func() {
+		DescribeTable("valid port specifications",
+			func(ports string) {
+				Expect(commands.ValidateReservablePorts(models.ReservablePorts(ports))).To(Succeed())
+			},
+			Entry("single port", "1024"),
+			Entry("range", "1024-1033"),
+			Entry("list", "1024-1033, 2000,3000-3010"),
+		)
+
+		DescribeTable("invalid port specifications",
+			func(ports string, message string) {
+				Expect(commands.ValidateReservablePorts(models.ReservablePorts(ports))).To(MatchError(ContainSubstring(message)))
+			},
+			Entry("empty", "", "empty port range"),
+			Entry("trailing comma", "1024,", "empty port range"),
+			Entry("not a number", "abc", `"abc" is not a valid port`),
+			Entry("out of range", "1024-70000", `"70000" is not a valid port`),
+			Entry("zero", "0-10", `"0" is not a valid port`),
+			Entry("descending", "2000-1000", "must be ascending"),
+			Entry("too many dashes", "1-2-3", "is not a port or port range"),
+			Entry("overlapping", "1000-2000,1500", "port ranges overlap"),
+		)
+	})
+
+	Describe(".ValidateRouterGroup", func() {
+		It("requires reservable ports for tcp router groups", func() {
+			group.ReservablePorts = ""
+			Expect(commands.ValidateRouterGroup(group)).To(MatchError(ContainSubstring("must have reservable_ports")))
+		})
+
+		It("allows http router groups without reservable ports", func() {
+			group.Type = models.RouterGroup_HTTP
+			group.ReservablePorts = ""
+			Expect(commands.ValidateRouterGroup(group)).To(Succeed())
+		})
+
+		It("rejects unknown types", func() {
+			group.Type = "udp"
+			Expect(commands.ValidateRouterGroup(group)).To(MatchError(ContainSubstring(`got "udp"`)))
+		})
+	})
+})
@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// RouteGroupFields are the fields routes can be grouped by.
+var RouteGroupFields = []string{"route", "ip", "log_guid", "route_service_url"}
+
+// RouteGroup counts the routes sharing the same value of a field, the
+// hostnames among them and their backends.
+type RouteGroup struct {
+	Key      string `json:"key"`
+	Routes   int    `json:"routes"`
+	Hosts    int    `json:"hosts"`
+	Backends int    `json:"backends"`
+}
+
+// GroupRoutes groups the routes by one of RouteGroupFields, with the largest
+// groups first.
+func GroupRoutes(routes []models.Route, field string) ([]RouteGroup, error) {
+	var key func(models.Route) string
+	switch field {
+	case "route":
+		key = func(route models.Route) string { return route.Route }
+	case "ip":
+		key = func(route models.Route) string { return route.IP }
+	case "log_guid":
+		key = func(route models.Route) string { return route.LogGuid }
+	case "route_service_url":
+		key = func(route models.Route) string { return route.RouteServiceUrl }
+	default:
+		return nil, fmt.Errorf("cannot group by %s, must be one of %s", field, strings.Join(RouteGroupFields, ", "))
+	}
+
+	groups := groupRoutes(routes, key)
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Routes != groups[j].Routes {
+			return groups[i].Routes > groups[j].Routes
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
+
+// TTLBucket counts the routes with a TTL of at most Max seconds, and above
+// the Max of the previous bucket. The bucket for the longest TTLs has no
+// Max, and is followed by one for the routes without TTL.
+type TTLBucket struct {
+	TTL    string `json:"ttl"`
+	Max    int    `json:"-"`
+	Routes int    `json:"routes"`
+}
+
+// Stats summarizes the routing table.
+type Stats struct {
+	Routes             int         `json:"routes"`
+	TcpRoutes          int         `json:"tcp_routes"`
+	UniqueHosts        int         `json:"unique_hosts"`
+	UniqueBackendIPs   int         `json:"unique_backend_ips"`
+	RouteServiceRoutes int         `json:"route_service_routes"`
+	TTLHistogram       []TTLBucket `json:"ttl_histogram"`
+	// TopHosts are the hostnames with the most backends, ignoring context
+	// paths.
+	TopHosts []RouteGroup `json:"top_hosts"`
+}
+
+// ComputeStats summarizes the HTTP routes and TCP route mappings, keeping the
+// top hostnames by backend count. Backend IPs are counted across both, the
+// other figures only cover HTTP routes.
+func ComputeStats(routes []models.Route, mappings []models.TcpRouteMapping, top int) Stats {
+	stats := Stats{
+		Routes:       len(routes),
+		TcpRoutes:    len(mappings),
+		TTLHistogram: newTTLHistogram(),
+	}
+
+	ips := map[string]bool{}
+	for _, route := range routes {
+		ips[route.IP] = true
+		if route.RouteServiceUrl != "" {
+			stats.RouteServiceRoutes++
+		}
+		countTTL(stats.TTLHistogram, route.TTL)
+	}
+	for _, mapping := range mappings {
+		ips[mapping.HostIP] = true
+	}
+	stats.UniqueBackendIPs = len(ips)
+
+	hosts := groupRoutes(routes, func(route models.Route) string { return routeHost(route.Route) })
+	stats.UniqueHosts = len(hosts)
+
+	sort.SliceStable(hosts, func(i, j int) bool {
+		if hosts[i].Backends != hosts[j].Backends {
+			return hosts[i].Backends > hosts[j].Backends
+		}
+		return hosts[i].Key < hosts[j].Key
+	})
+	if top >= 0 && len(hosts) > top {
+		hosts = hosts[:top]
+	}
+	stats.TopHosts = hosts
+
+	return stats
+}
+
+// groupRoutes groups the routes by key, in the order the keys first appear.
+func groupRoutes(routes []models.Route, key func(models.Route) string) []RouteGroup {
+	var groups []RouteGroup
+	indexes := map[string]int{}
+	hosts := map[string]map[string]bool{}
+	backends := map[string]map[string]bool{}
+
+	for _, route := range routes {
+		k := key(route)
+		i, found := indexes[k]
+		if !found {
+			i = len(groups)
+			indexes[k] = i
+			groups = append(groups, RouteGroup{Key: k})
+			hosts[k] = map[string]bool{}
+			backends[k] = map[string]bool{}
+		}
+
+		groups[i].Routes++
+		hosts[k][routeHost(route.Route)] = true
+		backends[k][route.IP+":"+strconv.Itoa(int(route.Port))] = true
+	}
+
+	for i := range groups {
+		groups[i].Hosts = len(hosts[groups[i].Key])
+		groups[i].Backends = len(backends[groups[i].Key])
+	}
+	return groups
+}
+
+// routeHost returns the hostname of a route, without its context path.
+func routeHost(route string) string {
+	host, _, _ := strings.Cut(route, "/")
+	return strings.ToLower(host)
+}
+
+func newTTLHistogram() []TTLBucket {
+	return []TTLBucket{
+		{TTL: "<= 30s", Max: 30},
+		{TTL: "<= 1m", Max: 60},
+		{TTL: "<= 2m", Max: 120},
+		{TTL: "<= 5m", Max: 300},
+		{TTL: "<= 1h", Max: 3600},
+		{TTL: "> 1h"},
+		{TTL: "unset"},
+	}
+}
+
+// countTTL counts the TTL in its bucket, or in the last one when it is unset.
+func countTTL(histogram []TTLBucket, ttl *int) {
+	unset := len(histogram) - 1
+	if ttl == nil {
+		histogram[unset].Routes++
+		return
+	}
+
+	for i := range histogram[:unset] {
+		if histogram[i].Max == 0 || *ttl <= histogram[i].Max {
+			histogram[i].Routes++
+			return
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stats", func() {
+	var routes []models.Route
+
+	BeforeEach(func() {
+		noTTL := models.NewRoute("c.example.com", 8080, "10.0.0.3", "team-b", "", 0)
+		noTTL.TTL = nil
+		routes = []models.Route{
+			models.NewRoute("a.example.com", 8080, "10.0.0.1", "team-a", "", 30),
+			models.NewRoute("a.example.com", 8080, "10.0.0.2", "team-a", "", 60),
+			models.NewRoute("A.example.com/path", 8081, "10.0.0.2", "team-a", "https://rs.example.com", 120),
+			models.NewRoute("b.example.com", 8080, "10.0.0.1", "team-b", "", 7200),
+			noTTL,
+		}
+	})
+
+	Describe(".GroupRoutes", func() {
+		It("counts the routes, hosts and backends of every group, largest first", func() {
+			groups, err := commands.GroupRoutes(routes, "ip")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groups).To(Equal([]commands.RouteGroup{
+				{Key: "10.0.0.1", Routes: 2, Hosts: 2, Backends: 1},
+				{Key: "10.0.0.2", Routes: 2, Hosts: 1, Backends: 2},
+				{Key: "10.0.0.3", Routes: 1, Hosts: 1, Backends: 1},
+			}))
+		})
+
+		It("rejects unknown fields", func() {
+			_, err := commands.GroupRoutes(routes, "port")
+			Expect(err).To(MatchError("cannot group by port, must be one of route, ip, log_guid, route_service_url"))
+		})
+	})
+
+	Describe(".ComputeStats", func() {
+		It("summarizes the routes", func() {
+			mappings := []models.TcpRouteMapping{
+				models.NewTcpRouteMapping("group", 1024, "10.0.0.9", 61000, 0, "", nil, 60, models.ModificationTag{}),
+			}
+
+			stats := commands.ComputeStats(routes, mappings, 2)
+			Expect(stats.Routes).To(Equal(5))
+			Expect(stats.TcpRoutes).To(Equal(1))
+			Expect(stats.UniqueHosts).To(Equal(3))
+			Expect(stats.UniqueBackendIPs).To(Equal(4))
+			Expect(stats.RouteServiceRoutes).To(Equal(1))
+			Expect(stats.TTLHistogram).To(Equal([]commands.TTLBucket{
+				{TTL: "<= 30s", Max: 30, Routes: 1},
+				{TTL: "<= 1m", Max: 60, Routes: 1},
+				{TTL: "<= 2m", Max: 120, Routes: 1},
+				{TTL: "<= 5m", Max: 300},
+				{TTL: "<= 1h", Max: 3600},
+				{TTL: "> 1h", Routes: 1},
+				{TTL: "unset", Routes: 1},
+			}))
+			Expect(stats.TopHosts).To(Equal([]commands.RouteGroup{
+				{Key: "a.example.com", Routes: 3, Hosts: 1, Backends: 3},
+				{Key: "b.example.com", Routes: 1, Hosts: 1, Backends: 1},
+			}))
+		})
+	})
+})
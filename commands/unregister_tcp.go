@@ -0,0 +1,10 @@
+package commands
+
+import (
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+func UnregisterTcp(client routing_api.Client, mappings []models.TcpRouteMapping) error {
+	return client.DeleteTcpRouteMappings(mappings)
+}
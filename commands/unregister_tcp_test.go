@@ -0,0 +1,27 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(".UnregisterTcp", func() {
+	var (
+		client *fake_routing_api.FakeClient
+	)
+
+	BeforeEach(func() {
+		client = &fake_routing_api.FakeClient{}
+	})
+
+	It("unregisters tcp route mappings", func() {
+		mappings := []models.TcpRouteMapping{{}}
+		commands.UnregisterTcp(client, mappings)
+		Expect(client.DeleteTcpRouteMappingsCallCount()).To(Equal(1))
+		Expect(client.DeleteTcpRouteMappingsArgsForCall(0)).To(Equal(mappings))
+	})
+
+})
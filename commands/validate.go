@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// maximumHostnameLength is the longest hostname DNS allows.
+const maximumHostnameLength = 253
+
+var hostnameLabelPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// RouteProblem is a problem of the route at Index.
+type RouteProblem struct {
+	Index   int
+	Route   string
+	Problem string
+}
+
+// ValidationError lists every problem found in a list of routes.
+type ValidationError struct {
+	Problems []RouteProblem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "found %d problems in the routes:", len(e.Problems))
+	for _, problem := range e.Problems {
+		fmt.Fprintf(&b, "\n  route %d (%s): %s", problem.Index, problem.Route, problem.Problem)
+	}
+	return b.String()
+}
+
+// ValidateRegister checks the routes to be registered before they are sent
+// to the routing-api, and returns a ValidationError listing all problems.
+func ValidateRegister(routes []models.Route) error {
+	return validateRoutes(routes, true)
+}
+
+// ValidateUnregister checks the routes to be unregistered. Their TTL and
+// route service are ignored by the routing-api, so they are not checked.
+func ValidateUnregister(routes []models.Route) error {
+	return validateRoutes(routes, false)
+}
+
+func validateRoutes(routes []models.Route, register bool) error {
+	var problems []RouteProblem
+	for i, route := range routes {
+		for _, problem := range routeProblems(route, register) {
+			problems = append(problems, RouteProblem{Index: i, Route: route.Route, Problem: problem})
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func routeProblems(route models.Route, register bool) []string {
+	var problems []string
+
+	if problem := hostnameProblem(route.Route); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	if net.ParseIP(route.IP) == nil {
+		problems = append(problems, fmt.Sprintf("invalid ip: %q", route.IP))
+	}
+
+	if route.Port == 0 {
+		problems = append(problems, "port must be between 1 and 65535")
+	}
+
+	if !register {
+		return problems
+	}
+
+	if route.TTL == nil || *route.TTL <= 0 {
+		problems = append(problems, "ttl must be positive")
+	}
+
+	if route.RouteServiceUrl != "" {
+		u, err := url.Parse(route.RouteServiceUrl)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("route_service_url must be an https URL: %s", route.RouteServiceUrl))
+		}
+	}
+
+	return problems
+}
+
+// hostnameProblem checks a route such as foo.example.com, *.example.com or
+// foo.example.com/context/path.
+func hostnameProblem(route string) string {
+	if route == "" {
+		return "missing route"
+	}
+
+	host, path, hasPath := strings.Cut(route, "/")
+	if host == "*" {
+		return "wildcard routes need a domain: *"
+	}
+	if len(host) > maximumHostnameLength {
+		return fmt.Sprintf("hostname is longer than %d characters", maximumHostnameLength)
+	}
+
+	for i, label := range strings.Split(host, ".") {
+		if i == 0 && label == "*" {
+			continue
+		}
+		if !hostnameLabelPattern.MatchString(label) {
+			return fmt.Sprintf("invalid hostname: %s", host)
+		}
+	}
+
+	if hasPath && strings.ContainsAny(path, " \t?#") {
+		return fmt.Sprintf("invalid context path: /%s", path)
+	}
+
+	return ""
+}
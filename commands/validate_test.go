@@ -0,0 +1,64 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	problems := func(err error) []commands.RouteProblem {
+		Expect(err).To(BeAssignableToTypeOf(&commands.ValidationError{}))
+		return err.(*commands.ValidationError).Problems
+	}
+
+	It("accepts valid routes", func() {
+		Expect(commands.ValidateRegister([]models.Route{
+			models.NewRoute("foo.example.com", 8080, "10.0.0.1", "", "", 60),
+			models.NewRoute("*.example.com", 8080, "10.0.0.1", "", "https://rs.example.com", 60),
+			models.NewRoute("foo.example.com/api/v1", 8080, "fd00::1", "", "", 60),
+			models.NewRoute("localhost", 1, "127.0.0.1", "", "", 1),
+		})).To(Succeed())
+	})
+
+	It("reports every problem with the index of its route", func() {
+		err := commands.ValidateRegister([]models.Route{
+			models.NewRoute("foo.example.com", 8080, "10.0.0.1", "", "", 60),
+			models.NewRoute("foo..example.com", 0, "10.0.0", "", "http://rs.example.com", 0),
+			{},
+		})
+		Expect(problems(err)).To(Equal([]commands.RouteProblem{
+			{Index: 1, Route: "foo..example.com", Problem: "invalid hostname: foo..example.com"},
+			{Index: 1, Route: "foo..example.com", Problem: `invalid ip: "10.0.0"`},
+			{Index: 1, Route: "foo..example.com", Problem: "port must be between 1 and 65535"},
+			{Index: 1, Route: "foo..example.com", Problem: "ttl must be positive"},
+			{Index: 1, Route: "foo..example.com", Problem: "route_service_url must be an https URL: http://rs.example.com"},
+			{Index: 2, Route: "", Problem: "missing route"},
+			{Index: 2, Route: "", Problem: `invalid ip: ""`},
+			{Index: 2, Route: "", Problem: "port must be between 1 and 65535"},
+			{Index: 2, Route: "", Problem: "ttl must be positive"},
+		}))
+		Expect(err).To(MatchError(HavePrefix("found 9 problems in the routes:\n  route 1 (foo..example.com): invalid hostname: foo..example.com\n")))
+	})
+
+	DescribeTable("rejects invalid hostnames",
+		func(route, problem string) {
+			err := commands.ValidateRegister([]models.Route{models.NewRoute(route, 8080, "10.0.0.1", "", "", 60)})
+			Expect(problems(err)).To(ConsistOf(commands.RouteProblem{Route: route, Problem: problem}))
+		},
+		Entry("wildcards only", "*", "wildcard routes need a domain: *"),
+		Entry("wildcards inside", "foo.*.example.com", "invalid hostname: foo.*.example.com"),
+		Entry("leading hyphens", "-foo.example.com", "invalid hostname: -foo.example.com"),
+		Entry("invalid characters", "foo_bar.example.com", "invalid hostname: foo_bar.example.com"),
+		Entry("query strings", "foo.example.com/path?query", "invalid context path: /path?query"),
+	)
+
+	It("only checks the backend of routes to unregister", func() {
+		route := models.NewRoute("foo.example.com", 8080, "10.0.0.1", "", "http://rs.example.com", 0)
+		Expect(commands.ValidateUnregister([]models.Route{route})).To(Succeed())
+
+		route.IP = "nope"
+		Expect(problems(commands.ValidateUnregister([]models.Route{route}))).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+const (
+	RowAdded   = "added"
+	RowUpdated = "updated"
+	RowRemoved = "removed"
+)
+
+// WatchRow is an HTTP route or a TCP route mapping of a RouteWatch. State
+// tells whether the row was added, updated or removed at Changed, and is
+// empty once that was longer ago than the highlight of the watch.
+type WatchRow struct {
+	Route    *models.Route
+	TcpRoute *models.TcpRouteMapping
+	State    string
+	Changed  time.Time
+}
+
+// RouteWatch keeps the routes and TCP route mappings passing Filter up to
+// date with route events. Changed rows are highlighted for Highlight, and
+// removed rows are kept that long before they are dropped.
+type RouteWatch struct {
+	Filter    RouteFilter
+	Highlight time.Duration
+	Clock     clock.Clock
+
+	rows map[string]*WatchRow
+}
+
+// Load replaces the rows with a snapshot of the routes and TCP route
+// mappings. The first snapshot is not highlighted, later ones highlight the
+// rows that changed since the previous one, e.g. after reconnecting.
+func (w *RouteWatch) Load(routes []models.Route, mappings []models.TcpRouteMapping) {
+	first := w.rows == nil
+	if first {
+		w.rows = map[string]*WatchRow{}
+	}
+
+	seen := map[string]bool{}
+	for i := range routes {
+		if w.Filter.Match(routes[i]) {
+			key := watchKey(&routes[i], nil)
+			seen[key] = true
+			w.upsert(key, WatchRow{Route: &routes[i]}, !first)
+		}
+	}
+	for i := range mappings {
+		if w.Filter.MatchTcp(mappings[i]) {
+			key := watchKey(nil, &mappings[i])
+			seen[key] = true
+			w.upsert(key, WatchRow{TcpRoute: &mappings[i]}, !first)
+		}
+	}
+
+	for key := range w.rows {
+		if !seen[key] {
+			w.remove(key)
+		}
+	}
+}
+
+// ApplyHttp applies an HTTP route event, and reports whether a row changed.
+func (w *RouteWatch) ApplyHttp(event routing_api.Event) bool {
+	route := event.Route
+	if !w.Filter.Match(route) {
+		return false
+	}
+
+	key := watchKey(&route, nil)
+	if removal(event.Action) {
+		return w.remove(key)
+	}
+	return w.upsert(key, WatchRow{Route: &route}, true)
+}
+
+// ApplyTcp applies a TCP route event, and reports whether a row changed.
+func (w *RouteWatch) ApplyTcp(event routing_api.TcpEvent) bool {
+	mapping := event.TcpRouteMapping
+	if !w.Filter.MatchTcp(mapping) {
+		return false
+	}
+
+	key := watchKey(nil, &mapping)
+	if removal(event.Action) {
+		return w.remove(key)
+	}
+	return w.upsert(key, WatchRow{TcpRoute: &mapping}, true)
+}
+
+// Rows returns the HTTP routes followed by the TCP route mappings, after
+// dropping the removed rows and clearing the states that are no longer
+// highlighted.
+func (w *RouteWatch) Rows() []WatchRow {
+	now := w.Clock.Now()
+
+	keys := make([]string, 0, len(w.rows))
+	for key, row := range w.rows {
+		if row.State != "" && now.Sub(row.Changed) >= w.Highlight {
+			if row.State == RowRemoved {
+				delete(w.rows, key)
+				continue
+			}
+			row.State = ""
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := make([]WatchRow, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, *w.rows[key])
+	}
+	return rows
+}
+
+func (w *RouteWatch) upsert(key string, row WatchRow, highlight bool) bool {
+	current, found := w.rows[key]
+	switch {
+	case !found || current.State == RowRemoved:
+		if highlight {
+			row.State, row.Changed = RowAdded, w.Clock.Now()
+		}
+	case watchRowChanged(*current, row):
+		row.State, row.Changed = RowUpdated, w.Clock.Now()
+	default:
+		row.State, row.Changed = current.State, current.Changed
+		w.rows[key] = &row
+		return false
+	}
+
+	w.rows[key] = &row
+	return true
+}
+
+func (w *RouteWatch) remove(key string) bool {
+	row, found := w.rows[key]
+	if !found || row.State == RowRemoved {
+		return false
+	}
+	row.State, row.Changed = RowRemoved, w.Clock.Now()
+	return true
+}
+
+// watchRowChanged reports whether a row changed in a way worth highlighting,
+// ignoring the modification tag that changes whenever a route is registered
+// again.
+func watchRowChanged(current, row WatchRow) bool {
+	if row.Route != nil {
+		return !sameTTL(current.Route.TTL, row.Route.TTL) ||
+			current.Route.LogGuid != row.Route.LogGuid ||
+			current.Route.RouteServiceUrl != row.Route.RouteServiceUrl
+	}
+	return !sameTTL(current.TcpRoute.TTL, row.TcpRoute.TTL) ||
+		current.TcpRoute.IsolationSegment != row.TcpRoute.IsolationSegment
+}
+
+// watchKey sorts HTTP routes before TCP route mappings.
+func watchKey(route *models.Route, mapping *models.TcpRouteMapping) string {
+	if route != nil {
+		return "1 " + routeKey(*route)
+	}
+	return "2 " + tcpRouteKey(*mapping)
+}
+
+func removal(action string) bool {
+	return strings.EqualFold(action, "delete") || strings.EqualFold(action, "expire")
+}
@@ -0,0 +1,95 @@
+package commands_test
+
+import (
+	"regexp"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RouteWatch", func() {
+	var (
+		clock *fakeclock.FakeClock
+		watch *commands.RouteWatch
+		route models.Route
+	)
+
+	states := func() map[string]string {
+		result := map[string]string{}
+		for _, row := range watch.Rows() {
+			if row.Route != nil {
+				result[row.Route.Route] = row.State
+			} else {
+				result[row.TcpRoute.HostIP] = row.State
+			}
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Now())
+		watch = &commands.RouteWatch{Highlight: 5 * time.Second, Clock: clock}
+		route = models.NewRoute("a.example.com", 8080, "10.0.0.1", "app-a", "", 60)
+
+		watch.Load([]models.Route{route}, []models.TcpRouteMapping{
+			models.NewTcpRouteMapping("group-guid", 1024, "10.0.1.1", 61000, 0, "", nil, 60, models.ModificationTag{}),
+		})
+	})
+
+	It("does not highlight the initial snapshot", func() {
+		Expect(states()).To(Equal(map[string]string{"a.example.com": "", "10.0.1.1": ""}))
+	})
+
+	It("highlights added, updated and removed rows until the highlight expires", func() {
+		added := models.NewRoute("b.example.com", 8080, "10.0.0.2", "app-b", "", 60)
+		Expect(watch.ApplyHttp(routing_api.Event{Action: "Upsert", Route: added})).To(BeTrue())
+
+		updated := route
+		updated.LogGuid = "app-c"
+		Expect(watch.ApplyHttp(routing_api.Event{Action: "Upsert", Route: updated})).To(BeTrue())
+
+		mapping := models.NewTcpRouteMapping("group-guid", 1024, "10.0.1.1", 61000, 0, "", nil, 60, models.ModificationTag{})
+		Expect(watch.ApplyTcp(routing_api.TcpEvent{Action: "Delete", TcpRouteMapping: mapping})).To(BeTrue())
+
+		Expect(states()).To(Equal(map[string]string{
+			"a.example.com": commands.RowUpdated,
+			"b.example.com": commands.RowAdded,
+			"10.0.1.1":      commands.RowRemoved,
+		}))
+
+		clock.Increment(5 * time.Second)
+		Expect(states()).To(Equal(map[string]string{"a.example.com": "", "b.example.com": ""}))
+	})
+
+	It("ignores routes registered again unchanged", func() {
+		again := route
+		again.ModificationTag = models.ModificationTag{Guid: "guid", Index: 2}
+		Expect(watch.ApplyHttp(routing_api.Event{Action: "Upsert", Route: again})).To(BeFalse())
+		Expect(states()).To(Equal(map[string]string{"a.example.com": "", "10.0.1.1": ""}))
+	})
+
+	It("ignores routes not passing the filter", func() {
+		pattern, err := commands.ParseRoutePattern("a.*")
+		Expect(err).NotTo(HaveOccurred())
+		watch.Filter = commands.RouteFilter{Routes: []*regexp.Regexp{pattern}}
+
+		other := models.NewRoute("b.example.com", 8080, "10.0.0.2", "app-b", "", 60)
+		Expect(watch.ApplyHttp(routing_api.Event{Action: "Upsert", Route: other})).To(BeFalse())
+	})
+
+	It("highlights the differences of later snapshots", func() {
+		added := models.NewRoute("b.example.com", 8080, "10.0.0.2", "app-b", "", 60)
+		watch.Load([]models.Route{route, added}, nil)
+
+		Expect(states()).To(Equal(map[string]string{
+			"a.example.com": "",
+			"b.example.com": commands.RowAdded,
+			"10.0.1.1":      commands.RowRemoved,
+		}))
+	})
+})
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	routing_api "code.cloudfoundry.org/routing-api"
+)
+
+// BackendQuery selects the backends in Network, on Port when it is not 0.
+type BackendQuery struct {
+	Network *net.IPNet
+	Port    int
+}
+
+// ParseBackendQuery parses an IP, an IP with a port such as 10.0.16.5:61000
+// or [fd00::5]:61000, or a CIDR such as 10.0.16.0/20.
+func ParseBackendQuery(value string) (BackendQuery, error) {
+	var query BackendQuery
+
+	if !strings.Contains(value, "/") {
+		if host, port, err := net.SplitHostPort(value); err == nil {
+			query.Port, err = strconv.Atoi(port)
+			if err != nil || query.Port < 1 || query.Port > 65535 {
+				return query, fmt.Errorf("invalid port: %s", port)
+			}
+			value = host
+		}
+	}
+
+	network, err := ParseNetwork(value)
+	if err != nil {
+		return query, err
+	}
+	query.Network = network
+	return query, nil
+}
+
+// Match reports whether the backend is selected.
+func (q BackendQuery) Match(ip string, port int) bool {
+	return containsIP([]*net.IPNet{q.Network}, ip) && (q.Port == 0 || q.Port == port)
+}
+
+// BackendRoute is an HTTP route or TCP route mapping to a backend. Route is
+// the hostname of HTTP routes and the SNI hostname of TCP route mappings,
+// Port the frontend port of TCP route mappings.
+type BackendRoute struct {
+	Type        string `json:"type"`
+	Backend     string `json:"backend"`
+	Route       string `json:"route"`
+	LogGuid     string `json:"log_guid"`
+	RouterGroup string `json:"router_group"`
+	Port        int    `json:"port"`
+}
+
+// WhoRoutesTo finds the HTTP routes and TCP route mappings to the selected
+// backends, sorted by backend. Router groups are shown by name.
+func WhoRoutesTo(client routing_api.Client, query BackendQuery) ([]BackendRoute, error) {
+	routes, err := List(client)
+	if err != nil {
+		return nil, err
+	}
+
+	found := []BackendRoute{}
+	for _, route := range routes {
+		if query.Match(route.IP, int(route.Port)) {
+			found = append(found, BackendRoute{
+				Type:    "http",
+				Backend: net.JoinHostPort(route.IP, strconv.Itoa(int(route.Port))),
+				Route:   route.Route,
+				LogGuid: route.LogGuid,
+			})
+		}
+	}
+
+	mappings, err := ListTcp(client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var names map[string]string
+	for _, mapping := range mappings {
+		if !query.Match(mapping.HostIP, int(mapping.HostPort)) {
+			continue
+		}
+
+		if names == nil {
+			names, err = routerGroupNames(client)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		backendRoute := BackendRoute{
+			Type:        "tcp",
+			Backend:     net.JoinHostPort(mapping.HostIP, strconv.Itoa(int(mapping.HostPort))),
+			RouterGroup: mapping.RouterGroupGuid,
+			Port:        int(mapping.ExternalPort),
+		}
+		if name, ok := names[mapping.RouterGroupGuid]; ok {
+			backendRoute.RouterGroup = name
+		}
+		if mapping.SniHostname != nil {
+			backendRoute.Route = *mapping.SniHostname
+		}
+		found = append(found, backendRoute)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.Backend != b.Backend {
+			return compareBackends(a.Backend, b.Backend) < 0
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Route < b.Route
+	})
+	return found, nil
+}
+
+func routerGroupNames(client routing_api.Client) (map[string]string, error) {
+	groups, err := ListRouterGroups(client)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(groups))
+	for _, group := range groups {
+		names[group.Guid] = group.Name
+	}
+	return names, nil
+}
+
+// compareBackends orders backends by IP, then by port.
+func compareBackends(a, b string) int {
+	hostA, portA, _ := net.SplitHostPort(a)
+	hostB, portB, _ := net.SplitHostPort(b)
+	if c := compareIPs(hostA, hostB); c != 0 {
+		return c
+	}
+	numberA, _ := strconv.Atoi(portA)
+	numberB, _ := strconv.Atoi(portB)
+	return numberA - numberB
+}
@@ -0,0 +1,96 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api/fake_routing_api"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WhoRoutesTo", func() {
+	Describe(".ParseBackendQuery", func() {
+		It("parses IPs with and without port", func() {
+			query, err := commands.ParseBackendQuery("10.0.0.5:61000")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(query.Port).To(Equal(61000))
+			Expect(query.Match("10.0.0.5", 61000)).To(BeTrue())
+			Expect(query.Match("10.0.0.5", 61001)).To(BeFalse())
+
+			query, err = commands.ParseBackendQuery("[fd00::5]:61000")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(query.Match("fd00::5", 61000)).To(BeTrue())
+
+			query, err = commands.ParseBackendQuery("10.0.0.5")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(query.Match("10.0.0.5", 8080)).To(BeTrue())
+			Expect(query.Match("10.0.0.6", 8080)).To(BeFalse())
+		})
+
+		It("parses CIDRs", func() {
+			query, err := commands.ParseBackendQuery("10.0.0.0/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(query.Match("10.0.0.200", 8080)).To(BeTrue())
+			Expect(query.Match("10.0.1.1", 8080)).To(BeFalse())
+		})
+
+		It("rejects invalid backends", func() {
+			_, err := commands.ParseBackendQuery("10.0.0.5:http")
+			Expect(err).To(MatchError("invalid port: http"))
+
+			_, err = commands.ParseBackendQuery("cell-1")
+			Expect(err).To(MatchError("invalid IP address: cell-1"))
+		})
+	})
+
+	Describe(".WhoRoutesTo", func() {
+		var client *fake_routing_api.FakeClient
+
+		BeforeEach(func() {
+			client = &fake_routing_api.FakeClient{}
+			client.RoutesReturns([]models.Route{
+				models.NewRoute("b.example.com", 61001, "10.0.0.5", "app-b", "", 60),
+				models.NewRoute("a.example.com", 61000, "10.0.0.5", "app-a", "", 60),
+				models.NewRoute("c.example.com", 61000, "10.0.1.5", "app-c", "", 60),
+			}, nil)
+			sni := "tls.example.com"
+			client.TcpRouteMappingsReturns([]models.TcpRouteMapping{
+				models.NewTcpRouteMapping("group-guid", 1024, "10.0.0.5", 61000, 0, "", &sni, 60, models.ModificationTag{}),
+			}, nil)
+			client.RouterGroupsReturns([]models.RouterGroup{{Guid: "group-guid", Name: "default-tcp"}}, nil)
+		})
+
+		It("finds the HTTP and TCP routes to the backends", func() {
+			query, err := commands.ParseBackendQuery("10.0.0.0/24")
+			Expect(err).NotTo(HaveOccurred())
+
+			routes, err := commands.WhoRoutesTo(client, query)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(Equal([]commands.BackendRoute{
+				{Type: "http", Backend: "10.0.0.5:61000", Route: "a.example.com", LogGuid: "app-a"},
+				{Type: "tcp", Backend: "10.0.0.5:61000", Route: "tls.example.com", RouterGroup: "default-tcp", Port: 1024},
+				{Type: "http", Backend: "10.0.0.5:61001", Route: "b.example.com", LogGuid: "app-b"},
+			}))
+		})
+
+		It("does not look up router groups without TCP routes to the backends", func() {
+			query, err := commands.ParseBackendQuery("10.0.1.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			routes, err := commands.WhoRoutesTo(client, query)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(1))
+			Expect(client.RouterGroupsCallCount()).To(Equal(0))
+		})
+
+		It("returns an empty list when nothing routes to the backends", func() {
+			query, err := commands.ParseBackendQuery("10.0.2.0/24")
+			Expect(err).NotTo(HaveOccurred())
+
+			routes, err := commands.WhoRoutesTo(client, query)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).NotTo(BeNil())
+			Expect(routes).To(BeEmpty())
+		})
+	})
+})
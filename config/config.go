@@ -0,0 +1,157 @@
+// Package config reads and writes the rtr config file, which holds the named
+// routing-api targets that commands fall back to when connection flags are
+// not given.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	RTR_CONFIG = "RTR_CONFIG"
+	fileName   = "config.yml"
+	dirName    = ".rtr"
+)
+
+type Target struct {
+	Name                string `yaml:"name"`
+	API                 string `yaml:"api"`
+	ClientID            string `yaml:"client_id"`
+	ClientSecret        string `yaml:"client_secret,omitempty"`
+	OAuthURL            string `yaml:"oauth_url"`
+	CACerts             string `yaml:"ca_certs,omitempty"`
+	SkipTLSVerification bool   `yaml:"skip_tls_verification,omitempty"`
+}
+
+type Config struct {
+	Current string   `yaml:"current,omitempty"`
+	Targets []Target `yaml:"targets"`
+}
+
+// Dir returns the directory holding the rtr config file and its companions,
+// ~/.rtr unless RTR_CONFIG points the config file elsewhere.
+func Dir() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(path), nil
+}
+
+// Path returns the location of the config file, ~/.rtr/config.yml unless
+// overridden with RTR_CONFIG.
+func Path() (string, error) {
+	if path := os.Getenv(RTR_CONFIG); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, dirName, fileName), nil
+}
+
+// Load reads the config file at path. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %s", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config to path. The file is only readable by its owner
+// because targets can hold client secrets.
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+func (c *Config) Target(name string) (Target, bool) {
+	for _, target := range c.Targets {
+		if target.Name == name {
+			return target, true
+		}
+	}
+	return Target{}, false
+}
+
+// CurrentTarget returns the target selected with Use, if any.
+func (c *Config) CurrentTarget() (Target, bool) {
+	if c.Current == "" {
+		return Target{}, false
+	}
+	return c.Target(c.Current)
+}
+
+// Add adds the target, replacing an existing target with the same name. The
+// first target added becomes the current one.
+func (c *Config) Add(target Target) error {
+	if target.Name == "" {
+		return fmt.Errorf("target name must not be empty")
+	}
+
+	for i, existing := range c.Targets {
+		if existing.Name == target.Name {
+			c.Targets[i] = target
+			return nil
+		}
+	}
+
+	c.Targets = append(c.Targets, target)
+	if len(c.Targets) == 1 {
+		c.Current = target.Name
+	}
+	return nil
+}
+
+func (c *Config) Use(name string) error {
+	if _, ok := c.Target(name); !ok {
+		return fmt.Errorf("unknown target: %s", name)
+	}
+	c.Current = name
+	return nil
+}
+
+// Remove removes the target, unsetting it if it is the current one.
+func (c *Config) Remove(name string) error {
+	for i, target := range c.Targets {
+		if target.Name == name {
+			c.Targets = append(c.Targets[:i], c.Targets[i+1:]...)
+			if c.Current == name {
+				c.Current = ""
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown target: %s", name)
+}
@@ -0,0 +1,122 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/routing-api-cli/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	var (
+		dir     string
+		path    string
+		cfg     *config.Config
+		staging config.Target
+		prod    config.Target
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "rtr-config")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, ".rtr", "config.yml")
+
+		cfg = &config.Config{}
+		staging = config.Target{Name: "staging", API: "https://api.staging", ClientID: "client", ClientSecret: "secret", OAuthURL: "https://uaa.staging:443"}
+		prod = config.Target{Name: "prod", API: "https://api.prod", ClientID: "client", ClientSecret: "secret", OAuthURL: "https://uaa.prod:443", SkipTLSVerification: true}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		os.Unsetenv(config.RTR_CONFIG)
+	})
+
+	Describe("Path", func() {
+		It("defaults to ~/.rtr/config.yml", func() {
+			home, err := os.UserHomeDir()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Path()).To(Equal(filepath.Join(home, ".rtr", "config.yml")))
+		})
+
+		It("can be overridden with RTR_CONFIG", func() {
+			os.Setenv(config.RTR_CONFIG, path)
+			Expect(config.Path()).To(Equal(path))
+			Expect(config.Dir()).To(Equal(filepath.Join(dir, ".rtr")))
+		})
+	})
+
+	Describe("Load and Save", func() {
+		It("treats a missing file as an empty config", func() {
+			loaded, err := config.Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.Targets).To(BeEmpty())
+		})
+
+		It("round trips the targets in a file only the owner can read", func() {
+			Expect(cfg.Add(staging)).To(Succeed())
+			Expect(cfg.Add(prod)).To(Succeed())
+			Expect(cfg.Save(path)).To(Succeed())
+
+			info, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			loaded, err := config.Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded).To(Equal(cfg))
+		})
+
+		It("reports invalid files", func() {
+			Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+			Expect(os.WriteFile(path, []byte("targets: {"), 0600)).To(Succeed())
+
+			_, err := config.Load(path)
+			Expect(err).To(MatchError(ContainSubstring("invalid config file")))
+		})
+	})
+
+	Describe("targets", func() {
+		It("makes the first target added the current one", func() {
+			Expect(cfg.Add(staging)).To(Succeed())
+			Expect(cfg.Add(prod)).To(Succeed())
+
+			current, ok := cfg.CurrentTarget()
+			Expect(ok).To(BeTrue())
+			Expect(current).To(Equal(staging))
+		})
+
+		It("replaces targets with the same name", func() {
+			Expect(cfg.Add(staging)).To(Succeed())
+			staging.API = "https://api.new"
+			Expect(cfg.Add(staging)).To(Succeed())
+
+			Expect(cfg.Targets).To(HaveLen(1))
+			Expect(cfg.Targets[0].API).To(Equal("https://api.new"))
+		})
+
+		It("switches the current target", func() {
+			Expect(cfg.Add(staging)).To(Succeed())
+			Expect(cfg.Add(prod)).To(Succeed())
+			Expect(cfg.Use("prod")).To(Succeed())
+
+			current, _ := cfg.CurrentTarget()
+			Expect(current).To(Equal(prod))
+			Expect(cfg.Use("dev")).To(MatchError("unknown target: dev"))
+		})
+
+		It("unsets the current target when it is removed", func() {
+			Expect(cfg.Add(staging)).To(Succeed())
+			Expect(cfg.Add(prod)).To(Succeed())
+			Expect(cfg.Remove("staging")).To(Succeed())
+
+			Expect(cfg.Targets).To(Equal([]config.Target{prod}))
+			_, ok := cfg.CurrentTarget()
+			Expect(ok).To(BeFalse())
+			Expect(cfg.Remove("staging")).To(MatchError("unknown target: staging"))
+		})
+	})
+})
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const tokensFileName = "tokens.yml"
+
+// Token is an access token cached by rtr login.
+type Token struct {
+	AccessToken string    `yaml:"access_token"`
+	Expiry      time.Time `yaml:"expiry"`
+}
+
+// Tokens holds the cached tokens by TokenKey.
+type Tokens map[string]Token
+
+// TokensPath returns the location of the token cache, next to the config file.
+func TokensPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, tokensFileName), nil
+}
+
+// TokenKey identifies the cached token of an OAuth client. The cache is kept
+// per client rather than per target: tokens are issued to the client, so
+// targets sharing a client and OAuth URL share the token, and connections
+// given as flags without a target are cached too.
+func TokenKey(oauthURL, clientID string) string {
+	return clientID + "@" + oauthURL
+}
+
+// Valid reports whether the token can still be used for buffer from now.
+func (t Token) Valid(now time.Time, buffer time.Duration) bool {
+	return t.AccessToken != "" && now.Add(buffer).Before(t.Expiry)
+}
+
+// LoadTokens reads the token cache at path. A missing file is an empty cache.
+func LoadTokens(path string) (Tokens, error) {
+	tokens := Tokens{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(data, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token cache %s: %s", path, err)
+	}
+	return tokens, nil
+}
+
+// Save writes the token cache to path, readable only by its owner. It writes
+// a temporary file first so that concurrent invocations never read a
+// partially written cache.
+func (t Tokens) Save(path string) error {
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), tokensFileName)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmp.Name(), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
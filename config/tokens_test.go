@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/routing-api-cli/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tokens", func() {
+	var (
+		dir  string
+		path string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "rtr-tokens")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "tokens.yml")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		os.Unsetenv(config.RTR_CONFIG)
+	})
+
+	It("lives next to the config file", func() {
+		os.Setenv(config.RTR_CONFIG, filepath.Join(dir, "config.yml"))
+		Expect(config.TokensPath()).To(Equal(path))
+	})
+
+	It("treats a missing file as an empty cache", func() {
+		tokens, err := config.LoadTokens(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tokens).To(BeEmpty())
+	})
+
+	It("round trips the tokens in a file only the owner can read", func() {
+		expiry := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+		tokens := config.Tokens{
+			config.TokenKey("https://uaa.example.com", "client"): {AccessToken: "some-token", Expiry: expiry},
+		}
+		Expect(tokens.Save(path)).To(Succeed())
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		loaded, err := config.LoadTokens(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded).To(HaveKey("client@https://uaa.example.com"))
+		Expect(loaded["client@https://uaa.example.com"].AccessToken).To(Equal("some-token"))
+		Expect(loaded["client@https://uaa.example.com"].Expiry.Equal(expiry)).To(BeTrue())
+	})
+
+	Describe("Valid", func() {
+		var now time.Time
+
+		BeforeEach(func() {
+			now = time.Now()
+		})
+
+		It("is valid until the buffer before expiry", func() {
+			token := config.Token{AccessToken: "some-token", Expiry: now.Add(time.Minute)}
+			Expect(token.Valid(now, 30*time.Second)).To(BeTrue())
+			Expect(token.Valid(now.Add(31*time.Second), 30*time.Second)).To(BeFalse())
+		})
+
+		It("is invalid without an access token or expiry", func() {
+			Expect(config.Token{Expiry: now.Add(time.Hour)}.Valid(now, 0)).To(BeFalse())
+			Expect(config.Token{AccessToken: "some-token"}.Valid(now, 0)).To(BeFalse())
+		})
+	})
+})
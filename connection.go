@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/routing-api-cli/config"
+	"github.com/urfave/cli"
+)
+
+// connection holds the settings needed to talk to a routing-api.
+type connection struct {
+	api                 string
+	clientID            string
+	clientSecret        string
+	oauthURL            string
+	caCerts             string
+	skipTLSVerification bool
+}
+
+// resolveConnection returns the connection flags when any of them is given,
+// and otherwise the target selected with --target, or the current target.
+// Flags are never combined with a target, so that the credentials of a
+// target are only ever sent to its own API and OAuth server.
+func resolveConnection(c *cli.Context) (connection, error) {
+	conn, err := flagConnection(c)
+	if err != nil || conn != (connection{}) {
+		return conn, err
+	}
+
+	target, found, err := selectedTarget(c.String("target"))
+	if err != nil || !found {
+		return conn, err
+	}
+
+	return connectionOf(target), nil
+}
+
+// targetConnection returns the settings of the target with the given name,
+// ignoring the connection flags.
+func targetConnection(name string) (connection, error) {
+	target, _, err := selectedTarget(name)
+	if err != nil {
+		return connection{}, err
+	}
+
+	return connectionOf(target), nil
+}
+
+// connectionOf returns the settings stored in a target.
+func connectionOf(target config.Target) connection {
+	return connection{
+		api:                 target.API,
+		clientID:            target.ClientID,
+		clientSecret:        target.ClientSecret,
+		oauthURL:            target.OAuthURL,
+		caCerts:             target.CACerts,
+		skipTLSVerification: target.SkipTLSVerification,
+	}
+}
+
+// flagConnection reads the connection flags, or their environment variables.
+// --client-secret-file takes precedence over --client-secret.
+func flagConnection(c *cli.Context) (connection, error) {
+	conn := connection{
+		api:                 c.String("api"),
+		clientID:            c.String("client-id"),
+		clientSecret:        c.String("client-secret"),
+		oauthURL:            c.String("oauth-url"),
+		caCerts:             c.String("ca-certs"),
+		skipTLSVerification: c.Bool("skip-tls-verification"),
+	}
+
+	if secretFile := c.String("client-secret-file"); secretFile != "" {
+		secret, err := os.ReadFile(secretFile)
+		if err != nil {
+			return conn, fmt.Errorf("Unable to read client secret file: %s", err)
+		}
+		conn.clientSecret = strings.TrimSpace(string(secret))
+	}
+
+	return conn, nil
+}
+
+// selectedTarget returns the target with the given name, or the current
+// target when name is empty.
+func selectedTarget(name string) (config.Target, bool, error) {
+	path, err := config.Path()
+	if err != nil {
+		return config.Target{}, false, err
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return config.Target{}, false, err
+	}
+
+	if name == "" {
+		target, found := cfg.CurrentTarget()
+		return target, found, nil
+	}
+
+	target, found := cfg.Target(name)
+	if !found {
+		return config.Target{}, false, fmt.Errorf("Unknown target: %s", name)
+	}
+	return target, true, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api-cli/output"
+	"github.com/urfave/cli"
+)
+
+var dryRunFlag = cli.BoolFlag{
+	Name:  "dry-run",
+	Usage: "Show what would change without sending the routes (optional)",
+}
+
+type routeChangeSummary struct {
+	Action                 string `json:"action"`
+	Route                  string `json:"route"`
+	IP                     string `json:"ip"`
+	Port                   uint16 `json:"port"`
+	TTL                    *int   `json:"ttl"`
+	RouteServiceUrl        string `json:"route_service_url"`
+	CurrentTTL             *int   `json:"current_ttl"`
+	CurrentRouteServiceUrl string `json:"current_route_service_url"`
+}
+
+type routeChangeSummaries []routeChangeSummary
+
+func (changes routeChangeSummaries) Header() []string {
+	return []string{"action", "route", "ip", "port", "ttl", "route_service_url", "current_ttl", "current_route_service_url"}
+}
+
+func (changes routeChangeSummaries) Rows() [][]string {
+	rows := make([][]string, 0, len(changes))
+	for _, change := range changes {
+		rows = append(rows, []string{
+			change.Action,
+			change.Route,
+			change.IP,
+			strconv.Itoa(int(change.Port)),
+			output.FormatTTL(change.TTL),
+			change.RouteServiceUrl,
+			output.FormatTTL(change.CurrentTTL),
+			change.CurrentRouteServiceUrl,
+		})
+	}
+	return rows
+}
+
+func summarizeRouteChanges(changes []commands.RouteChange) routeChangeSummaries {
+	summaries := make(routeChangeSummaries, 0, len(changes))
+	for _, change := range changes {
+		summary := routeChangeSummary{
+			Action:          change.Action,
+			Route:           change.Route.Route,
+			IP:              change.Route.IP,
+			Port:            change.Route.Port,
+			TTL:             change.Route.TTL,
+			RouteServiceUrl: change.Route.RouteServiceUrl,
+		}
+		if change.Current != nil {
+			summary.CurrentTTL = change.Current.TTL
+			summary.CurrentRouteServiceUrl = change.Current.RouteServiceUrl
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// printDryRun prints the changes as a table, followed by how many routes
+// would change.
+func printDryRun(errorMessage string, changes []commands.RouteChange, unregister bool) {
+	err := output.Write(os.Stdout, output.Table, summarizeRouteChanges(changes))
+	checkError(errorMessage, err)
+
+	counts := commands.CountChanges(changes)
+	fmt.Println()
+	if unregister {
+		fmt.Printf("Dry run: would delete %d routes, %d deletes would be no-ops\n",
+			counts[commands.ChangeDelete], counts[commands.ChangeNoOp])
+		return
+	}
+	fmt.Printf("Dry run: would create %d routes, update %d routes and leave %d routes unchanged\n",
+		counts[commands.ChangeCreate], counts[commands.ChangeUpdate], counts[commands.ChangeUnchanged])
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"github.com/urfave/cli"
+)
+
+var exportCommand = cli.Command{
+	Name:      "export",
+	Usage:     "Writes the router groups, HTTP routes and TCP routes to an archive",
+	ArgsUsage: "<file>",
+	Action:    exportRoutes,
+	Flags:     flags,
+}
+
+var importCommand = cli.Command{
+	Name:      "import",
+	Usage:     "Restores the router groups, HTTP routes and TCP routes of an archive",
+	ArgsUsage: "<file>",
+	Description: `Router groups are matched by name. TCP routes are moved to the router group
+of the same name, which may have another guid than the exported one.`,
+	Action: importRoutes,
+	Flags: append(flags,
+		cli.StringSliceFlag{
+			Name:  "only",
+			Usage: "Restore only http, tcp or router-groups, can be repeated (optional)",
+		},
+		cli.IntFlag{
+			Name:  "ttl-override",
+			Usage: "Register the restored routes with this TTL in seconds instead of the exported one (optional)",
+		},
+	),
+}
+
+func exportRoutes(c *cli.Context) {
+	errorMessage := "exporting routes failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "export")...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "export")
+	}
+
+	conn, err := resolveConnection(c)
+	checkError(errorMessage, err)
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	metadata := commands.ArchiveMetadata{API: conn.api, ExportedAt: time.Now().UTC()}
+	if target, found, err := selectedTarget(c.String("target")); err == nil && found {
+		metadata.Target = target.Name
+	}
+
+	archive, err := commands.Export(client, metadata)
+	checkError(errorMessage, err)
+
+	name := c.Args().First()
+	file, err := os.Create(name)
+	checkError(errorMessage, err)
+
+	err = commands.WriteArchive(file, archive)
+	if err != nil {
+		file.Close()
+		checkError(errorMessage, err)
+	}
+	checkError(errorMessage, file.Close())
+
+	counts := archive.Metadata.Counts
+	fmt.Printf("Successfully exported %d router groups, %d routes and %d tcp routes to %s\n",
+		counts.RouterGroups, counts.Routes, counts.TcpRoutes, name)
+}
+
+func importRoutes(c *cli.Context) {
+	errorMessage := "importing routes failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "import")...)
+	issues = append(issues, checkImportFlags(c)...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "import")
+	}
+
+	file, err := os.Open(c.Args().First())
+	checkError(errorMessage, err)
+	defer file.Close()
+
+	archive, err := commands.ReadArchive(file)
+	checkError(errorMessage, err)
+
+	metadata := archive.Metadata
+	from := metadata.API
+	if metadata.Target != "" {
+		from = metadata.Target + " (" + metadata.API + ")"
+	}
+	fmt.Printf("Importing the archive exported from %s at %s\n", from, metadata.ExportedAt.Format(time.RFC3339))
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	summary, err := commands.Import(client, archive, c.StringSlice("only"), c.Int("ttl-override"))
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully imported %d routes and %d tcp routes, created %d and updated %d router groups\n",
+		summary.Routes, summary.TcpRoutes, summary.RouterGroupsCreated, summary.RouterGroupsUpdated)
+}
+
+func checkImportFlags(c *cli.Context) []string {
+	var issues []string
+
+	for _, part := range c.StringSlice("only") {
+		if !contains(commands.ArchiveParts, part) {
+			issues = append(issues, fmt.Sprintf("Invalid --only: %s, must be one of %s.", part, strings.Join(commands.ArchiveParts, ", ")))
+		}
+	}
+
+	if c.Int("ttl-override") < 0 {
+		issues = append(issues, "TTL override must not be negative.")
+	}
+
+	return issues
+}
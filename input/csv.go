@@ -0,0 +1,109 @@
+package input
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// csvFields sets the route field named by a CSV header column.
+var csvFields = map[string]func(route *models.Route, value string) error{
+	"route": func(route *models.Route, value string) error {
+		route.Route = value
+		return nil
+	},
+	"ip": func(route *models.Route, value string) error {
+		route.IP = value
+		return nil
+	},
+	"port": func(route *models.Route, value string) error {
+		port, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid port: %s", value)
+		}
+		route.Port = uint16(port)
+		return nil
+	},
+	"ttl": func(route *models.Route, value string) error {
+		ttl, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid ttl: %s", value)
+		}
+		route.TTL = &ttl
+		return nil
+	},
+	"log_guid": func(route *models.Route, value string) error {
+		route.LogGuid = value
+		return nil
+	},
+	"route_service_url": func(route *models.Route, value string) error {
+		route.RouteServiceUrl = value
+		return nil
+	},
+}
+
+// utf8BOM starts the CSV files exported by spreadsheets such as Excel.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// decodeCSV decodes one route per row. The header row names the JSON fields
+// of the columns, empty values are left unset.
+func decodeCSV(data []byte) ([]models.Route, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+
+	setters := make([]func(*models.Route, string) error, len(header))
+	for i, name := range header {
+		setter, found := csvFields[strings.TrimSpace(name)]
+		if !found {
+			line, column := reader.FieldPos(i)
+			return nil, &DecodeError{Line: line, Column: column, Err: fmt.Errorf("unknown column: %s", name)}
+		}
+		setters[i] = setter
+	}
+
+	var routes []models.Route
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return routes, nil
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+
+		var route models.Route
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+			if err := setters[i](&route, value); err != nil {
+				line, column := reader.FieldPos(i)
+				return nil, &DecodeError{Line: line, Column: column, Err: err}
+			}
+		}
+		routes = append(routes, route)
+	}
+}
+
+func csvError(err error) error {
+	var parseError *csv.ParseError
+	if errors.As(err, &parseError) {
+		return &DecodeError{Line: parseError.Line, Column: parseError.Column, Err: parseError.Err}
+	}
+	return err
+}
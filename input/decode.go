@@ -0,0 +1,114 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+const (
+	JSON = "json"
+	YAML = "yaml"
+	CSV  = "csv"
+)
+
+var Formats = []string{JSON, YAML, CSV}
+
+// ValidateFormat checks that format is known. The empty format detects the
+// format of files by their extension.
+func ValidateFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range Formats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of: %s", strings.Join(Formats, ", "))
+}
+
+// DetectFormat returns the format of a file by its extension, JSON for
+// unknown extensions and stdin.
+func DetectFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yml", ".yaml":
+		return YAML
+	case ".csv":
+		return CSV
+	default:
+		return JSON
+	}
+}
+
+// DecodeError reports where in its input decoding failed. Column is zero
+// when only the line is known.
+type DecodeError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeRoutes decodes routes in the given format: a JSON array, YAML
+// documents holding a list of routes or a single route, or CSV with a header
+// row naming the JSON fields of the routes.
+func DecodeRoutes(format string, data []byte) ([]models.Route, error) {
+	switch format {
+	case YAML:
+		return decodeYAML(data)
+	case CSV:
+		return decodeCSV(data)
+	default:
+		return decodeJSON(data)
+	}
+}
+
+func decodeJSON(data []byte) ([]models.Route, error) {
+	var routes []models.Route
+	err := json.Unmarshal(data, &routes)
+	if err != nil {
+		return nil, jsonError(data, err)
+	}
+	return routes, nil
+}
+
+// jsonError locates JSON syntax and type errors in data.
+func jsonError(data []byte, err error) error {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxError):
+		return offsetError(data, syntaxError.Offset, err)
+	case errors.As(err, &typeError):
+		return offsetError(data, typeError.Offset, err)
+	default:
+		return err
+	}
+}
+
+// offsetError locates the byte offset of a JSON decoding error.
+func offsetError(data []byte, offset int64, err error) error {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return &DecodeError{Line: line, Column: column, Err: err}
+}
@@ -0,0 +1,109 @@
+package input_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DecodeRoutes", func() {
+	It("detects formats by extension", func() {
+		Expect(input.DetectFormat("routes.yml")).To(Equal(input.YAML))
+		Expect(input.DetectFormat("routes.YAML")).To(Equal(input.YAML))
+		Expect(input.DetectFormat("routes.csv")).To(Equal(input.CSV))
+		Expect(input.DetectFormat("routes.json")).To(Equal(input.JSON))
+		Expect(input.DetectFormat(input.Stdin)).To(Equal(input.JSON))
+	})
+
+	It("validates formats", func() {
+		Expect(input.ValidateFormat("")).To(Succeed())
+		Expect(input.ValidateFormat(input.CSV)).To(Succeed())
+		Expect(input.ValidateFormat("xml")).To(MatchError("must be one of: json, yaml, csv"))
+	})
+
+	Describe("JSON", func() {
+		It("reports the line and column of type errors", func() {
+			_, err := input.DecodeRoutes(input.JSON, []byte("[\n  {\"route\": \"a.com\",\n   \"port\": \"80\"}\n]"))
+			Expect(err).To(MatchError(HavePrefix("line 3, column ")))
+			Expect(err).To(MatchError(ContainSubstring("cannot unmarshal string")))
+		})
+	})
+
+	Describe("YAML", func() {
+		It("decodes lists of routes and single routes from several documents", func() {
+			routes, err := input.DecodeRoutes(input.YAML, []byte(`
+- route: a.com
+  port: 8080
+  ip: 10.0.0.1
+  ttl: 60
+  log_guid: a-guid
+---
+route: b.com
+route_service_url: https://rs.example.com
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(2))
+			Expect(routes[0].Route).To(Equal("a.com"))
+			Expect(routes[0].Port).To(Equal(uint16(8080)))
+			Expect(routes[0].IP).To(Equal("10.0.0.1"))
+			Expect(*routes[0].TTL).To(Equal(60))
+			Expect(routes[0].LogGuid).To(Equal("a-guid"))
+			Expect(routes[1].RouteServiceUrl).To(Equal("https://rs.example.com"))
+		})
+
+		It("reports the line and column of invalid routes", func() {
+			_, err := input.DecodeRoutes(input.YAML, []byte("- route: a.com\n- route: b.com\n  port: eighty\n"))
+			Expect(err).To(MatchError(HavePrefix("line 2, column 3: ")))
+			Expect(err).To(MatchError(ContainSubstring("cannot unmarshal string")))
+		})
+
+		It("reports the line of syntax errors", func() {
+			_, err := input.DecodeRoutes(input.YAML, []byte("- route: a.com\n  port: [\n"))
+			var decodeError *input.DecodeError
+			Expect(err).To(BeAssignableToTypeOf(decodeError))
+			Expect(err.(*input.DecodeError).Line).To(BeNumerically(">", 0))
+		})
+
+		It("rejects documents that are not routes", func() {
+			_, err := input.DecodeRoutes(input.YAML, []byte("a.com\n"))
+			Expect(err).To(MatchError("line 1, column 1: expected a list of routes or a route"))
+		})
+	})
+
+	Describe("CSV", func() {
+		It("maps the columns by the header row", func() {
+			routes, err := input.DecodeRoutes(input.CSV, []byte("ip,port,route,ttl,log_guid,route_service_url\n10.0.0.1,8080,a.com,60,a-guid,\n10.0.0.2,8081,b.com,,,https://rs.example.com\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(2))
+			Expect(routes[0].Route).To(Equal("a.com"))
+			Expect(routes[0].IP).To(Equal("10.0.0.1"))
+			Expect(routes[0].Port).To(Equal(uint16(8080)))
+			Expect(*routes[0].TTL).To(Equal(60))
+			Expect(routes[0].LogGuid).To(Equal("a-guid"))
+			Expect(routes[1].TTL).To(BeNil())
+			Expect(routes[1].RouteServiceUrl).To(Equal("https://rs.example.com"))
+		})
+
+		It("ignores the byte order mark spreadsheets start files with", func() {
+			routes, err := input.DecodeRoutes(input.CSV, []byte("\xef\xbb\xbfroute,port\na.com,80\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Route).To(Equal("a.com"))
+		})
+
+		It("reports the line and column of invalid values", func() {
+			_, err := input.DecodeRoutes(input.CSV, []byte("route,port\na.com,80\nb.com,http\n"))
+			Expect(err).To(MatchError("line 3, column 7: invalid port: http"))
+		})
+
+		It("rejects unknown columns", func() {
+			_, err := input.DecodeRoutes(input.CSV, []byte("route,hostname\n"))
+			Expect(err).To(MatchError("line 1, column 7: unknown column: hostname"))
+		})
+
+		It("reports rows with the wrong number of fields", func() {
+			_, err := input.DecodeRoutes(input.CSV, []byte("route,port\na.com\n"))
+			Expect(err).To(MatchError(HavePrefix("line 2, column 1: ")))
+		})
+	})
+})
@@ -0,0 +1,45 @@
+package input
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// ParseBackend splits a backend given as ip:port, or [ip]:port for IPv6.
+func ParseBackend(value string) (string, uint16, error) {
+	ip, portValue, err := net.SplitHostPort(value)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid backend %s: %s", value, err)
+	}
+
+	port, err := strconv.ParseUint(portValue, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid backend %s: invalid port: %s", value, portValue)
+	}
+	return ip, uint16(port), nil
+}
+
+// BuildRoutes returns the route, or a copy of it for each of the backends
+// with the IP and port of the backend.
+func BuildRoutes(route models.Route, backends []string) ([]models.Route, error) {
+	if len(backends) == 0 {
+		return []models.Route{route}, nil
+	}
+
+	routes := make([]models.Route, 0, len(backends))
+	for _, backend := range backends {
+		ip, port, err := ParseBackend(backend)
+		if err != nil {
+			return nil, err
+		}
+
+		r := route
+		r.IP = ip
+		r.Port = port
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
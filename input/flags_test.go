@@ -0,0 +1,43 @@
+package input_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/input"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BuildRoutes", func() {
+	var route models.Route
+
+	BeforeEach(func() {
+		route = models.NewRoute("foo.com", 8080, "10.0.0.1", "log-guid", "", 60)
+	})
+
+	It("returns the route without backends", func() {
+		routes, err := input.BuildRoutes(route, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(Equal([]models.Route{route}))
+	})
+
+	It("fans the route out to the backends", func() {
+		routes, err := input.BuildRoutes(route, []string{"10.0.0.2:61000", "[fd00::1]:61001"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(HaveLen(2))
+		Expect(routes[0].Route).To(Equal("foo.com"))
+		Expect(routes[0].IP).To(Equal("10.0.0.2"))
+		Expect(routes[0].Port).To(Equal(uint16(61000)))
+		Expect(*routes[0].TTL).To(Equal(60))
+		Expect(routes[0].LogGuid).To(Equal("log-guid"))
+		Expect(routes[1].IP).To(Equal("fd00::1"))
+		Expect(routes[1].Port).To(Equal(uint16(61001)))
+	})
+
+	It("rejects invalid backends", func() {
+		_, err := input.BuildRoutes(route, []string{"10.0.0.2"})
+		Expect(err).To(MatchError(HavePrefix("invalid backend 10.0.0.2: ")))
+
+		_, err = input.BuildRoutes(route, []string{"10.0.0.2:http"})
+		Expect(err).To(MatchError("invalid backend 10.0.0.2:http: invalid port: http"))
+	})
+})
@@ -0,0 +1,131 @@
+// Package input reads the routes given to register and unregister, either as
+// a command line argument or from files and stdin, in JSON, YAML or CSV.
+package input
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// Stdin is the file name that stands for standard input.
+const Stdin = "-"
+
+// Expand resolves file arguments to the files they name. Directories stand
+// for the regular files directly inside them, and patterns such as
+// routes/*.json for the files they match, both in lexical order. Stdin is
+// kept as it is, but may only be given once.
+func Expand(names []string) ([]string, error) {
+	var files []string
+	stdin := false
+
+	for _, name := range names {
+		if name == Stdin {
+			if stdin {
+				return nil, fmt.Errorf("stdin can only be read once")
+			}
+			stdin = true
+			files = append(files, name)
+			continue
+		}
+
+		if strings.ContainsAny(name, "*?[") {
+			matches, err := filepath.Glob(name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%s: no files match", name)
+			}
+			files = append(files, matches...)
+			continue
+		}
+
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, name)
+			continue
+		}
+
+		entries, err := os.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		var dirFiles []string
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				dirFiles = append(dirFiles, filepath.Join(name, entry.Name()))
+			}
+		}
+		if len(dirFiles) == 0 {
+			return nil, fmt.Errorf("%s: directory holds no files", name)
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+
+	return files, nil
+}
+
+// ReadRoutes reads the routes from the given files and concatenates them.
+// Stdin is read from stdin. The files are decoded in the given format, or in
+// the format detected from their extension when it is empty.
+func ReadRoutes(names []string, format string, stdin io.Reader) ([]models.Route, error) {
+	var routes []models.Route
+	err := readFiles(names, format, stdin, func(format string, data []byte) error {
+		fileRoutes, err := DecodeRoutes(format, data)
+		routes = append(routes, fileRoutes...)
+		return err
+	})
+	return routes, err
+}
+
+// readFiles hands the content and format of each of the given files to
+// decode, and prefixes the errors decoding them with the file name.
+func readFiles(names []string, format string, stdin io.Reader, decode func(format string, data []byte) error) error {
+	files, err := Expand(names)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := readFile(file, stdin)
+		if err != nil {
+			return err
+		}
+
+		fileFormat := format
+		if fileFormat == "" {
+			fileFormat = DetectFormat(file)
+		}
+
+		err = decode(fileFormat, data)
+		if err != nil {
+			return fmt.Errorf("%s: %s", displayName(file), err)
+		}
+	}
+
+	return nil
+}
+
+func readFile(name string, stdin io.Reader) ([]byte, error) {
+	if name == Stdin {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(name)
+}
+
+func displayName(name string) string {
+	if name == Stdin {
+		return "stdin"
+	}
+	return name
+}
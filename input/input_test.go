@@ -0,0 +1,98 @@
+package input_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/routing-api-cli/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReadRoutes", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "routing-api-cli-input")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	It("concatenates the routes of several files", func() {
+		first := writeFile("a.json", `[{"route":"a.com","port":1,"ip":"1.1.1.1"}]`)
+		second := writeFile("b.json", `[{"route":"b.com","port":2,"ip":"2.2.2.2"},{"route":"c.com"}]`)
+
+		routes, err := input.ReadRoutes([]string{first, second}, "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(HaveLen(3))
+		Expect(routes[0].Route).To(Equal("a.com"))
+		Expect(routes[2].Route).To(Equal("c.com"))
+	})
+
+	It("reads stdin for -", func() {
+		routes, err := input.ReadRoutes([]string{input.Stdin}, "", strings.NewReader(`[{"route":"a.com"}]`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(HaveLen(1))
+	})
+
+	It("only reads stdin once", func() {
+		_, err := input.ReadRoutes([]string{input.Stdin, input.Stdin}, "", strings.NewReader(`[]`))
+		Expect(err).To(MatchError("stdin can only be read once"))
+	})
+
+	It("reads the files of directories and patterns in lexical order", func() {
+		writeFile("b.json", `[{"route":"b.com"}]`)
+		writeFile("a.json", `[{"route":"a.com"}]`)
+		Expect(os.Mkdir(filepath.Join(dir, "nested"), 0755)).To(Succeed())
+
+		routes, err := input.ReadRoutes([]string{dir}, "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(HaveLen(2))
+		Expect(routes[0].Route).To(Equal("a.com"))
+
+		routes, err = input.ReadRoutes([]string{filepath.Join(dir, "*.json")}, "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(HaveLen(2))
+	})
+
+	It("fails for patterns without matches", func() {
+		_, err := input.ReadRoutes([]string{filepath.Join(dir, "*.json")}, "", nil)
+		Expect(err).To(MatchError(ContainSubstring("no files match")))
+	})
+
+	It("names the file holding invalid JSON", func() {
+		path := writeFile("bad.json", `[{"route":`)
+		_, err := input.ReadRoutes([]string{path}, "", nil)
+		Expect(err).To(MatchError(path + ": line 1, column 11: unexpected end of JSON input"))
+	})
+
+	It("detects the format of each file by its extension", func() {
+		first := writeFile("a.yml", "- route: a.com\n  port: 1\n")
+		second := writeFile("b.csv", "route,port\nb.com,2\n")
+
+		routes, err := input.ReadRoutes([]string{first, second}, "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(HaveLen(2))
+		Expect(routes[0].Route).To(Equal("a.com"))
+		Expect(routes[1].Port).To(Equal(uint16(2)))
+	})
+
+	It("decodes every file in the given format", func() {
+		path := writeFile("routes.txt", "route: a.com\n")
+
+		routes, err := input.ReadRoutes([]string{path}, input.YAML, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,58 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"code.cloudfoundry.org/routing-api/models"
+	"gopkg.in/yaml.v3"
+)
+
+// State is the desired set of HTTP routes and TCP route mappings that plan
+// and apply compare with the registered ones.
+type State struct {
+	Routes    []models.Route           `json:"routes"`
+	TcpRoutes []models.TcpRouteMapping `json:"tcp_routes"`
+}
+
+// ReadState reads the desired state from the given files and merges them.
+// Each file holds a JSON or YAML object with routes and tcp_routes lists.
+func ReadState(names []string, format string, stdin io.Reader) (State, error) {
+	var state State
+	err := readFiles(names, format, stdin, func(format string, data []byte) error {
+		fileState, err := DecodeState(format, data)
+		state.Routes = append(state.Routes, fileState.Routes...)
+		state.TcpRoutes = append(state.TcpRoutes, fileState.TcpRoutes...)
+		return err
+	})
+	return state, err
+}
+
+// DecodeState decodes a desired state in JSON or YAML. YAML is converted
+// through JSON, so that it uses the same field names.
+func DecodeState(format string, data []byte) (State, error) {
+	var state State
+	switch format {
+	case YAML:
+		var value interface{}
+		err := yaml.Unmarshal(data, &value)
+		if err != nil {
+			return state, yamlError(err)
+		}
+		data, err = json.Marshal(value)
+		if err != nil {
+			return state, err
+		}
+		err = json.Unmarshal(data, &state)
+		return state, err
+	case CSV:
+		return state, fmt.Errorf("the desired state cannot be read from CSV")
+	default:
+		err := json.Unmarshal(data, &state)
+		if err != nil {
+			return state, jsonError(data, err)
+		}
+		return state, nil
+	}
+}
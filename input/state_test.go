@@ -0,0 +1,46 @@
+package input_test
+
+import (
+	"code.cloudfoundry.org/routing-api-cli/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DecodeState", func() {
+	It("decodes HTTP routes and TCP route mappings from YAML", func() {
+		state, err := input.DecodeState(input.YAML, []byte(`
+routes:
+- route: a.com
+  port: 8080
+  ip: 10.0.0.1
+  ttl: 60
+tcp_routes:
+- router_group_guid: group-guid
+  port: 1024
+  backend_ip: 10.0.0.2
+  backend_port: 61000
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.Routes).To(HaveLen(1))
+		Expect(state.Routes[0].Route).To(Equal("a.com"))
+		Expect(state.TcpRoutes).To(HaveLen(1))
+		Expect(state.TcpRoutes[0].ExternalPort).To(Equal(uint16(1024)))
+		Expect(state.TcpRoutes[0].HostIP).To(Equal("10.0.0.2"))
+	})
+
+	It("decodes JSON", func() {
+		state, err := input.DecodeState(input.JSON, []byte(`{"routes":[{"route":"a.com"}]}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.Routes).To(HaveLen(1))
+	})
+
+	It("reports the position of JSON errors", func() {
+		_, err := input.DecodeState(input.JSON, []byte(`{"routes":[{"route":1}]}`))
+		Expect(err).To(MatchError(HavePrefix("line 1, column ")))
+	})
+
+	It("does not decode CSV", func() {
+		_, err := input.DecodeState(input.CSV, []byte("route\na.com\n"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,90 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"code.cloudfoundry.org/routing-api/models"
+	"gopkg.in/yaml.v3"
+)
+
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// decodeYAML decodes every document, holding either a list of routes or a
+// single route. The routes are converted through JSON, so that they use the
+// same field names.
+func decodeYAML(data []byte) ([]models.Route, error) {
+	var routes []models.Route
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return routes, nil
+		}
+		if err != nil {
+			return nil, yamlError(err)
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+
+		node := document.Content[0]
+		switch node.Kind {
+		case yaml.SequenceNode:
+			for _, item := range node.Content {
+				route, err := decodeYAMLRoute(item)
+				if err != nil {
+					return nil, err
+				}
+				routes = append(routes, route)
+			}
+		case yaml.MappingNode:
+			route, err := decodeYAMLRoute(node)
+			if err != nil {
+				return nil, err
+			}
+			routes = append(routes, route)
+		default:
+			return nil, &DecodeError{Line: node.Line, Column: node.Column, Err: fmt.Errorf("expected a list of routes or a route")}
+		}
+	}
+}
+
+func decodeYAMLRoute(node *yaml.Node) (models.Route, error) {
+	var route models.Route
+	if node.Kind != yaml.MappingNode {
+		return route, &DecodeError{Line: node.Line, Column: node.Column, Err: fmt.Errorf("expected a route")}
+	}
+
+	var value interface{}
+	err := node.Decode(&value)
+	if err == nil {
+		var data []byte
+		data, err = json.Marshal(value)
+		if err == nil {
+			err = json.Unmarshal(data, &route)
+		}
+	}
+	if err != nil {
+		return route, &DecodeError{Line: node.Line, Column: node.Column, Err: err}
+	}
+	return route, nil
+}
+
+// yamlError turns the line of YAML syntax errors into a DecodeError. The
+// YAML decoder does not report their column.
+func yamlError(err error) error {
+	match := yamlLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(match[1])
+	return &DecodeError{Line: line, Err: errors.New(match[2])}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api-cli/output"
+	"github.com/urfave/cli"
+)
+
+var lintCommand = cli.Command{
+	Name:   "lint",
+	Usage:  "Reports conflicting and suspicious routes, and fails when any is an error",
+	Action: lintRoutes,
+	Flags: append(flags,
+		outputFlag,
+		cli.IntFlag{
+			Name:  "max-ttl",
+			Value: 3600,
+			Usage: "Warn about routes with a longer TTL in seconds, 0 disables the check (optional)",
+		},
+	),
+}
+
+type lintProblems []commands.LintProblem
+
+func (problems lintProblems) Header() []string {
+	return []string{"severity", "check", "subject", "message"}
+}
+
+func (problems lintProblems) Rows() [][]string {
+	rows := make([][]string, 0, len(problems))
+	for _, problem := range problems {
+		rows = append(rows, []string{problem.Severity, problem.Check, problem.Subject, problem.Message})
+	}
+	return rows
+}
+
+func lintRoutes(c *cli.Context) {
+	errorMessage := "linting routes failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "lint")...)
+	issues = append(issues, checkOutputFormat(c)...)
+	if c.Int("max-ttl") < 0 {
+		issues = append(issues, "Max TTL must not be negative.")
+	}
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "lint")
+	}
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	problems, err := commands.Lint(client, commands.LintOptions{MaxTTL: c.Int("max-ttl")})
+	checkError(errorMessage, err)
+
+	counts := commands.CountSeverities(problems)
+	if c.String("output") == output.Table {
+		if len(problems) > 0 {
+			printRecords(c, errorMessage, lintProblems(problems))
+			fmt.Println()
+		}
+		fmt.Printf("Found %d errors, %d warnings and %d infos\n",
+			counts[commands.SeverityError], counts[commands.SeverityWarning], counts[commands.SeverityInfo])
+	} else {
+		printRecords(c, errorMessage, lintProblems(problems))
+	}
+
+	if counts[commands.SeverityError] > 0 {
+		os.Exit(3)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/routing-api-cli/config"
+	"github.com/urfave/cli"
+)
+
+// tokenExpirationBuffer is how long before its expiry a cached token is
+// replaced, so that it does not expire during a request.
+const tokenExpirationBuffer = time.Duration(DefaultExpirationBufferTime) * time.Second
+
+func login(c *cli.Context) {
+	errorMessage := "login failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "login")...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "login")
+	}
+
+	conn, err := resolveConnection(c)
+	checkError(errorMessage, err)
+
+	token, err := fetchToken(conn)
+	checkError(errorMessage, err)
+
+	err = cacheToken(conn, token)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully logged in as %s, the token expires at %s\n", conn.clientID, token.Expiry.Format(time.RFC3339))
+}
+
+func logout(c *cli.Context) {
+	errorMessage := "logout failed:"
+	issues := checkArguments(c, "logout")
+
+	var conn connection
+	if !c.Bool("all") {
+		var err error
+		conn, err = resolveConnection(c)
+		if err != nil {
+			issues = append(issues, err.Error())
+		}
+		if conn.clientID == "" {
+			issues = append(issues, "Must provide the id of an OAuth client.")
+		}
+		if conn.oauthURL == "" {
+			issues = append(issues, "Must provide an URL to the OAuth client.")
+		}
+	}
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "logout")
+	}
+
+	path, err := config.TokensPath()
+	checkError(errorMessage, err)
+
+	if c.Bool("all") {
+		err = os.Remove(path)
+		if !os.IsNotExist(err) {
+			checkError(errorMessage, err)
+		}
+
+		fmt.Println("Successfully removed all cached tokens")
+		return
+	}
+
+	tokens, err := config.LoadTokens(path)
+	checkError(errorMessage, err)
+
+	delete(tokens, config.TokenKey(conn.oauthURL, conn.clientID))
+	err = tokens.Save(path)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully logged out %s\n", conn.clientID)
+}
+
+// accessToken returns the token cached by rtr login while it is valid, and
+// fetches a new one otherwise. A cached token that expired is replaced in the
+// cache, so that the client stays logged in.
+func accessToken(conn connection) (config.Token, error) {
+	path, err := config.TokensPath()
+	if err != nil {
+		return config.Token{}, err
+	}
+
+	tokens, err := config.LoadTokens(path)
+	if err != nil {
+		return config.Token{}, err
+	}
+
+	cached, loggedIn := tokens[config.TokenKey(conn.oauthURL, conn.clientID)]
+	if cached.Valid(time.Now(), tokenExpirationBuffer) {
+		return cached, nil
+	}
+
+	token, err := fetchToken(conn)
+	if err != nil {
+		return config.Token{}, err
+	}
+
+	if loggedIn {
+		err = cacheToken(conn, token)
+		if err != nil {
+			return config.Token{}, err
+		}
+	}
+	return token, nil
+}
+
+func fetchToken(conn connection) (config.Token, error) {
+	tokenFetcher, err := newTokenFetcher(conn)
+	if err != nil {
+		return config.Token{}, err
+	}
+
+	token, err := tokenFetcher.FetchToken(context.Background(), true)
+	if err != nil {
+		return config.Token{}, err
+	}
+	return config.Token{AccessToken: token.AccessToken, Expiry: token.Expiry}, nil
+}
+
+func cacheToken(conn connection, token config.Token) error {
+	path, err := config.TokensPath()
+	if err != nil {
+		return err
+	}
+
+	tokens, err := config.LoadTokens(path)
+	if err != nil {
+		return err
+	}
+
+	tokens[config.TokenKey(conn.oauthURL, conn.clientID)] = token
+	return tokens.Save(path)
+}
@@ -1,11 +1,11 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"net/url"
 	"os"
 	"strconv"
@@ -19,6 +19,9 @@ import (
 
 	routing_api "code.cloudfoundry.org/routing-api"
 	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api-cli/config"
+	"code.cloudfoundry.org/routing-api-cli/input"
+	"code.cloudfoundry.org/routing-api-cli/output"
 	"code.cloudfoundry.org/routing-api/trace"
 	uaaclient "code.cloudfoundry.org/routing-api/uaaclient"
 	"github.com/urfave/cli"
@@ -34,34 +37,165 @@ const (
 var version string
 
 var skipVerificationFlag = cli.BoolFlag{
-	Name:  "skip-tls-verification, k",
-	Usage: "Skip OAuth TLS Verification (optional)",
+	Name:   "skip-tls-verification, k",
+	Usage:  "Skip OAuth TLS Verification (optional)",
+	EnvVar: "RTR_SKIP_TLS_VERIFICATION",
 }
 
-var flags = []cli.Flag{
+var connectionFlags = []cli.Flag{
 	cli.StringFlag{
-		Name:  "api",
-		Usage: "Endpoint for the routing-api. (required)",
+		Name:   "api",
+		Usage:  "Endpoint for the routing-api. (required)",
+		EnvVar: "RTR_API",
 	},
 	cli.StringFlag{
-		Name:  "client-id",
-		Usage: "Id of the OAuth client. (required)",
+		Name:   "client-id",
+		Usage:  "Id of the OAuth client. (required)",
+		EnvVar: "RTR_CLIENT_ID",
 	},
 	cli.StringFlag{
-		Name:  "client-secret",
-		Usage: "Secret for OAuth client. (required)",
+		Name:   "client-secret",
+		Usage:  "Secret for OAuth client. (required)",
+		EnvVar: "RTR_CLIENT_SECRET",
 	},
 	cli.StringFlag{
-		Name:  "oauth-url",
-		Usage: "URL for OAuth client. (required)",
+		Name:   "client-secret-file",
+		Usage:  "File holding the secret for OAuth client, instead of --client-secret (optional)",
+		EnvVar: "RTR_CLIENT_SECRET_FILE",
+	},
+	cli.StringFlag{
+		Name:   "oauth-url",
+		Usage:  "URL for OAuth client. (required)",
+		EnvVar: "RTR_OAUTH_URL",
 	},
 	skipVerificationFlag,
 	cli.StringFlag{
-		Name:  "ca-certs",
-		Usage: "CA for UAA client (optional)",
+		Name:   "ca-certs",
+		Usage:  "CA for UAA client (optional)",
+		EnvVar: "RTR_CA_CERTS",
+	},
+}
+
+var targetFlag = cli.StringFlag{
+	Name:   "target",
+	Usage:  "Name of a configured target to use instead of the current one (optional)",
+	EnvVar: "RTR_TARGET",
+}
+
+// flags is capped at its length so that commands appending their own flags
+// to it never share a backing array.
+var flags = append(connectionFlags, targetFlag)[: len(connectionFlags)+1 : len(connectionFlags)+1]
+
+var outputFlag = cli.StringFlag{
+	Name:  "output, o",
+	Value: output.JSON,
+	Usage: "Output format: " + strings.Join(output.Formats, ", "),
+}
+
+var routesFileFlag = cli.StringSliceFlag{
+	Name:  "file, f",
+	Usage: "Read the routes JSON from this file, directory or pattern, or from stdin for -, can be repeated (optional)",
+}
+
+var inputFormatFlag = cli.StringFlag{
+	Name:  "input-format",
+	Usage: "Format of the routes: " + strings.Join(input.Formats, ", ") + " (default: by file extension, or json)",
+}
+
+var noValidateFlag = cli.BoolFlag{
+	Name:  "no-validate",
+	Usage: "Send the routes without checking them first (optional)",
+}
+
+var routeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "route",
+		Usage: "Hostname of a single route, instead of routes JSON (optional)",
+	},
+	cli.StringFlag{
+		Name:  "ip",
+		Usage: "IP of the backend of the route given with --route (optional)",
+	},
+	cli.IntFlag{
+		Name:  "port",
+		Usage: "Port of the backend of the route given with --route (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "backend",
+		Usage: "ip:port of a backend of the route given with --route, instead of --ip and --port, can be repeated (optional)",
+	},
+	cli.IntFlag{
+		Name:  "ttl",
+		Usage: "TTL of the route given with --route (optional)",
+	},
+	cli.StringFlag{
+		Name:  "log-guid",
+		Usage: "Log guid of the route given with --route (optional)",
+	},
+	cli.StringFlag{
+		Name:  "route-service-url",
+		Usage: "Route service URL of the route given with --route (optional)",
+	},
+}
+
+// routeFlagNames are the names of the routeFlags.
+var routeFlagNames = []string{"route", "ip", "port", "backend", "ttl", "log-guid", "route-service-url"}
+
+var registerStreamFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "stream",
+		Usage: "Register newline-delimited route JSON from stdin in batches (optional)",
+	},
+	cli.IntFlag{
+		Name:  "batch-size",
+		Value: 100,
+		Usage: "Maximum number of routes registered at once with --stream (optional)",
+	},
+	cli.DurationFlag{
+		Name:  "flush-interval",
+		Value: time.Second,
+		Usage: "Time after which a batch that is not full is registered with --stream (optional)",
 	},
 }
 
+var routeFilterFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "route",
+		Usage: "List the routes matching this glob, or this regular expression written as /regex/, can be repeated (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "ip",
+		Usage: "List the routes with an IP in this CIDR, or with this IP, can be repeated (optional)",
+	},
+	cli.IntSliceFlag{
+		Name:  "port",
+		Usage: "List the routes with this port, can be repeated (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "log-guid",
+		Usage: "List the routes with this log guid, can be repeated (optional)",
+	},
+	cli.BoolFlag{
+		Name:  "has-route-service",
+		Usage: "List only the routes with a route service (optional)",
+	},
+}
+
+var listFlags = append(append([]cli.Flag{outputFlag}, routeFilterFlags...),
+	cli.StringFlag{
+		Name:  "sort-by",
+		Usage: "Sort the routes by " + strings.Join(commands.RouteSortFields, ", ") + " (optional)",
+	},
+	cli.IntFlag{
+		Name:  "limit",
+		Usage: "List at most this many routes, or groups with --group-by (optional)",
+	},
+	cli.StringFlag{
+		Name:  "group-by",
+		Usage: "Count the routes, hosts and backends by " + strings.Join(commands.RouteGroupFields, ", ") + " (optional)",
+	},
+)
+
 var eventsFlags = []cli.Flag{
 	cli.BoolFlag{
 		Name:  "http",
@@ -71,6 +205,50 @@ var eventsFlags = []cli.Flag{
 		Name:  "tcp",
 		Usage: "Stream TCP events",
 	},
+	cli.IntFlag{
+		Name:  "max-retries",
+		Usage: "Number of times the routing-api client retries connecting an event stream, a second apart (optional)",
+	},
+	cli.DurationFlag{
+		Name:  "retry-backoff",
+		Value: time.Second,
+		Usage: "Time to wait before reconnecting with --forever, doubled for every failure in a row (optional)",
+	},
+	cli.BoolFlag{
+		Name:  "forever",
+		Usage: "Reconnect failed event streams without a limit (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "action",
+		Usage: "Only print events with this action, upsert or delete, can be repeated (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "route",
+		Usage: "Only print events of hostnames matching this glob, or this regular expression written as /regex/, can be repeated (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "ip",
+		Usage: "Only print events of backends in this CIDR or with this IP, can be repeated (optional)",
+	},
+	cli.IntSliceFlag{
+		Name:  "port",
+		Usage: "Only print events of this port, can be repeated (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "router-group",
+		Usage: "Only print TCP events of this router group name or guid, can be repeated (optional)",
+	},
+	cli.StringSliceFlag{
+		Name:  "isolation-segment",
+		Usage: "Only print TCP events of this isolation segment, can be repeated (optional)",
+	},
+}
+
+var logoutFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "all",
+		Usage: "Remove the cached access tokens of all targets",
+	},
 }
 
 var cliCommands = []cli.Command{
@@ -78,23 +256,27 @@ var cliCommands = []cli.Command{
 		Name:  "register",
 		Usage: "Registers routes with the routing-api",
 		Description: `Routes must be specified in JSON format, like so:
-'[{"route":"foo.com", "port":12345, "ip":"1.2.3.4", "ttl":5, "log_guid":"log-guid"}]'`,
+'[{"route":"foo.com", "port":12345, "ip":"1.2.3.4", "ttl":5, "log_guid":"log-guid"}]'
+or read with --file from JSON, YAML or CSV files with the same field names.
+A single route can also be given with --route and the other route flags.`,
 		Action: registerRoutes,
-		Flags:  flags,
+		Flags:  append(append(append(flags, routesFileFlag, inputFormatFlag, noValidateFlag, dryRunFlag), routeFlags...), registerStreamFlags...),
 	},
 	{
 		Name:  "unregister",
 		Usage: "Unregisters routes with the routing-api",
 		Description: `Routes must be specified in JSON format, like so:
-'[{"route":"foo.com", "port":12345, "ip":"1.2.3.4"]'`,
+'[{"route":"foo.com", "port":12345, "ip":"1.2.3.4"]'
+or read with --file from JSON, YAML or CSV files with the same field names.
+A single route can also be given with --route and the other route flags.`,
 		Action: unregisterRoutes,
-		Flags:  flags,
+		Flags:  append(append(flags, routesFileFlag, inputFormatFlag, noValidateFlag, dryRunFlag), routeFlags...),
 	},
 	{
 		Name:   "list",
 		Usage:  "Lists the currently registered routes",
 		Action: listRoutes,
-		Flags:  flags,
+		Flags:  append(flags, listFlags...),
 	},
 	{
 		Name:   "events",
@@ -102,10 +284,43 @@ var cliCommands = []cli.Command{
 		Action: streamEvents,
 		Flags:  append(flags, eventsFlags...),
 	},
+	planCommand,
+	applyCommand,
+	exportCommand,
+	importCommand,
+	migrateCommand,
+	statsCommand,
+	whoRoutesToCommand,
+	lintCommand,
+	watchCommand,
+	routerGroupsCommand,
+	tcpRoutesCommand,
+	targetCommand,
+	{
+		Name:   "login",
+		Usage:  "Fetches an access token and caches it for the following commands",
+		Action: login,
+		Flags:  flags,
+	},
+	{
+		Name:   "logout",
+		Usage:  "Removes the cached access token",
+		Action: logout,
+		Flags:  append(flags, logoutFlags...),
+	},
 }
 
 var environmentVariableHelp = `ENVIRONMENT VARIABLES:
-   RTR_TRACE=true	Print API request diagnostics to stdout`
+   RTR_TRACE=true	Print API request diagnostics to stdout
+   RTR_CONFIG=path	Location of the config file holding the targets (default: ~/.rtr/config.yml)
+   RTR_TARGET=name	Same as --target
+   RTR_API=url	Same as --api
+   RTR_CLIENT_ID=id	Same as --client-id
+   RTR_CLIENT_SECRET=secret	Same as --client-secret
+   RTR_CLIENT_SECRET_FILE=path	Same as --client-secret-file
+   RTR_OAUTH_URL=url	Same as --oauth-url
+   RTR_CA_CERTS=path	Same as --ca-certs
+   RTR_SKIP_TLS_VERIFICATION=true	Same as --skip-tls-verification`
 
 func main() {
 	lagerflags.AddFlags(flag.CommandLine)
@@ -134,54 +349,158 @@ func main() {
 func registerRoutes(c *cli.Context) {
 	issues := checkFlags(c)
 	errorMessage := "route registration failed:"
+	if c.Bool("stream") {
+		issues = append(issues, checkStreamArguments(c)...)
+		if len(issues) > 0 {
+			printHelpForCommand(c, issues, "register")
+		}
+		streamRoutes(c)
+		return
+	}
 	issues = append(issues, checkArguments(c, "register")...)
+	issues = append(issues, checkInputFormat(c)...)
 
 	if len(issues) > 0 {
 		printHelpForCommand(c, issues, "register")
 	}
 
-	desiredRoutes := c.Args().First()
-	var routes []models.Route
-
-	err := json.Unmarshal([]byte(desiredRoutes), &routes)
+	routes, desiredRoutes, err := readRoutes(c)
 	checkError(errorMessage, err)
 
+	if !c.Bool("no-validate") {
+		checkError(errorMessage, commands.ValidateRegister(routes))
+	}
+
 	client, err := newRoutingApiClient(c)
 	checkError(errorMessage, err)
 
+	if c.Bool("dry-run") {
+		changes, err := commands.DryRunRegister(client, routes)
+		checkError(errorMessage, err)
+		printDryRun(errorMessage, changes, false)
+		return
+	}
+
 	err = commands.Register(client, routes)
 	checkError(errorMessage, err)
 
 	fmt.Printf("Successfully registered routes: %s\n", desiredRoutes)
 }
 
+// streamRoutes registers the routes read from stdin, printing every batch and
+// a summary once stdin ends. It fails when any route failed.
+func streamRoutes(c *cli.Context) {
+	errorMessage := "route registration failed:"
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	stream := commands.RouteStream{
+		Client:        client,
+		BatchSize:     c.Int("batch-size"),
+		FlushInterval: c.Duration("flush-interval"),
+		Registered: func(batch int, routes []models.Route, err error) {
+			if err != nil {
+				fmt.Printf("Batch %d: failed to register %d routes: %s\n", batch, len(routes), err)
+				return
+			}
+			fmt.Printf("Batch %d: registered %d routes\n", batch, len(routes))
+		},
+		Invalid: func(line int, err error) {
+			fmt.Printf("Line %d: invalid route: %s\n", line, err)
+		},
+		Validate: !c.Bool("no-validate"),
+		Clock:    clock.NewClock(),
+	}
+
+	summary, err := stream.Run(os.Stdin)
+	fmt.Printf("Registered %d routes in %d batches, %d routes failed, %d lines invalid\n",
+		summary.Registered, summary.Batches, summary.Failed, summary.Invalid)
+	checkError(errorMessage, err)
+
+	if summary.Failed > 0 || summary.Invalid > 0 {
+		os.Exit(3)
+	}
+}
+
 func unregisterRoutes(c *cli.Context) {
 	issues := checkFlags(c)
 	errorMessage := "route unregistration failed:"
 	issues = append(issues, checkArguments(c, "unregister")...)
+	issues = append(issues, checkInputFormat(c)...)
 
 	if len(issues) > 0 {
 		printHelpForCommand(c, issues, "unregister")
 	}
 
-	desiredRoutes := c.Args().First()
-	var routes []models.Route
-	err := json.Unmarshal([]byte(desiredRoutes), &routes)
+	routes, desiredRoutes, err := readRoutes(c)
 	checkError(errorMessage, err)
 
+	if !c.Bool("no-validate") {
+		checkError(errorMessage, commands.ValidateUnregister(routes))
+	}
+
 	client, err := newRoutingApiClient(c)
 	checkError(errorMessage, err)
 
+	if c.Bool("dry-run") {
+		changes, err := commands.DryRunUnregister(client, routes)
+		checkError(errorMessage, err)
+		printDryRun(errorMessage, changes, true)
+		return
+	}
+
 	err = commands.UnRegister(client, routes)
 	checkError(errorMessage, err)
 
 	fmt.Printf("Successfully unregistered routes: %s\n", desiredRoutes)
 }
 
+// readRoutes returns the routes given as argument, with --file or with the
+// route flags, and how to refer to them in messages.
+func readRoutes(c *cli.Context) ([]models.Route, string, error) {
+	if routeFlagsGiven(c) {
+		route := models.Route{
+			Route:           c.String("route"),
+			IP:              c.String("ip"),
+			Port:            uint16(c.Int("port")),
+			LogGuid:         c.String("log-guid"),
+			RouteServiceUrl: c.String("route-service-url"),
+		}
+		if c.IsSet("ttl") {
+			ttl := c.Int("ttl")
+			route.TTL = &ttl
+		}
+
+		routes, err := input.BuildRoutes(route, c.StringSlice("backend"))
+		if err != nil {
+			return nil, "", err
+		}
+		desiredRoutes, err := json.Marshal(routes)
+		return routes, string(desiredRoutes), err
+	}
+
+	format := c.String("input-format")
+	files := c.StringSlice("file")
+	if len(files) == 0 {
+		if format == "" {
+			format = input.JSON
+		}
+		routes, err := input.DecodeRoutes(format, []byte(c.Args().First()))
+		return routes, c.Args().First(), err
+	}
+
+	routes, err := input.ReadRoutes(files, format, os.Stdin)
+	return routes, fmt.Sprintf("%d routes from %s", len(routes), strings.Join(files, ", ")), err
+}
+
 func listRoutes(c *cli.Context) {
 	errorMessage := "listing routes failed:"
 	issues := checkFlags(c)
 	issues = append(issues, checkArguments(c, "list")...)
+	issues = append(issues, checkOutputFormat(c)...)
+	options, listIssues := listOptions(c)
+	issues = append(issues, listIssues...)
 
 	if len(issues) > 0 {
 		printHelpForCommand(c, issues, "list")
@@ -190,20 +509,38 @@ func listRoutes(c *cli.Context) {
 	client, err := newRoutingApiClient(c)
 	checkError(errorMessage, err)
 
-	routes, err := commands.List(client)
+	if field := c.String("group-by"); field != "" {
+		limit := options.Limit
+		options.Limit = 0
+		routes, err := commands.ListFiltered(client, options)
+		checkError(errorMessage, err)
+
+		groups, err := commands.GroupRoutes(routes, field)
+		checkError(errorMessage, err)
+		if limit > 0 && len(groups) > limit {
+			groups = groups[:limit]
+		}
+
+		printRecords(c, errorMessage, routeGroups(groups))
+		return
+	}
+
+	routes, err := commands.ListFiltered(client, options)
 	if err != nil {
 		fmt.Println("listing routes failed:", err)
 		os.Exit(3)
 	}
 
-	prettyRoutes, _ := json.Marshal(routes)
-
-	fmt.Printf("%v\n", string(prettyRoutes))
+	printRecords(c, errorMessage, output.Routes(routes))
 }
 
 func streamEvents(c *cli.Context) {
 	issues := checkFlags(c)
 	issues = append(issues, checkArguments(c, "events")...)
+	issues = append(issues, checkRetryFlags(c)...)
+
+	filter, filterIssues := eventFilter(c)
+	issues = append(issues, filterIssues...)
 
 	if len(issues) > 0 {
 		printHelpForCommand(c, issues, "events")
@@ -217,8 +554,23 @@ func streamEvents(c *cli.Context) {
 		streamTcp = true
 	}
 
-	client, err := newRoutingApiClient(c)
+	conn, err := resolveConnection(c)
 	checkError("streaming events failed:", err)
+
+	token, err := accessToken(conn)
+	checkError("streaming events failed:", err)
+
+	client := routing_api.NewClient(conn.api, conn.skipTLSVerification)
+	client.SetToken(token.AccessToken)
+
+	filter.RouterGroupGuids, err = routerGroupGuids(client, c.StringSlice("router-group"))
+	checkError("streaming events failed:", err)
+
+	refresher := newTokenRefresher(conn, client, token)
+	stop := make(chan struct{})
+	defer close(stop)
+	go refresher.Run(stop)
+
 	errorChan := make(chan error)
 	eventChan := make(chan string)
 
@@ -226,12 +578,12 @@ func streamEvents(c *cli.Context) {
 
 	if streamHttp {
 		numOfSubscriptions++
-		go streamHttpEvents(client, eventChan, errorChan)
+		go streamHttpEvents(newEventStream(c, client, refresher, "HTTP", eventChan), filter, eventChan, errorChan)
 	}
 
 	if streamTcp {
 		numOfSubscriptions++
-		go streamTcpEvents(client, eventChan, errorChan)
+		go streamTcpEvents(newEventStream(c, client, refresher, "TCP", eventChan), filter, eventChan, errorChan)
 	}
 
 	errorCount := 0
@@ -251,62 +603,228 @@ loop:
 	}
 }
 
-func streamHttpEvents(client routing_api.Client, eventChan chan string, errorChan chan error) {
-	eventSource, err := client.SubscribeToEvents()
-	if err != nil {
-		fmt.Println("streaming events failed:", err)
-		return
+// newTokenRefresher refreshes the token of the client, which has to be the
+// given one of the connection, for long running subscriptions.
+func newTokenRefresher(conn connection, client routing_api.Client, token config.Token) *commands.TokenRefresher {
+	return &commands.TokenRefresher{
+		Client: client,
+		Fetch: func() (string, time.Time, error) {
+			token, err := fetchToken(conn)
+			return token.AccessToken, token.Expiry, err
+		},
+		Expiry:        token.Expiry,
+		Buffer:        tokenExpirationBuffer,
+		RetryInterval: DefaultTokenFetchRetryInterval,
+		Failed: func(err error) {
+			fmt.Println("Refreshing the access token failed:", err)
+		},
+		Clock: clock.NewClock(),
 	}
-	for {
-		e, err := eventSource.Next()
-		if err != nil {
-			errorChan <- err
-			break
+}
+
+// newEventStream returns an event stream that reports resubscribing with a
+// refreshed token on eventChan, and reconnect attempts on stderr so that
+// they do not mix with the events.
+func newEventStream(c *cli.Context, client routing_api.Client, refresher *commands.TokenRefresher, kind string, eventChan chan string) commands.EventStream {
+	return commands.EventStream{
+		Client:    client,
+		Refresher: refresher,
+		Resubscribed: func() {
+			eventChan <- fmt.Sprintf("Refreshed the access token, resubscribed to %s events", kind)
+		},
+		MaxRetries: uint16(c.Int("max-retries")),
+		Forever:    c.Bool("forever"),
+		Backoff:    c.Duration("retry-backoff"),
+		Reconnecting: func(attempt int, delay time.Duration, err error) {
+			fmt.Fprintf(os.Stderr, "%s event stream failed: %s, reconnecting in %s (attempt %d)\n", kind, err, delay, attempt)
+		},
+		Clock: clock.NewClock(),
+	}
+}
+
+func streamHttpEvents(stream commands.EventStream, filter commands.EventFilter, eventChan chan string, errorChan chan error) {
+	errorChan <- stream.StreamHttp(func(e routing_api.Event) {
+		if !filter.MatchHttp(e) {
+			return
 		}
+		event, _ := json.Marshal(e)
+		eventChan <- fmt.Sprintf("%v\n", string(event))
+	})
+}
 
+func streamTcpEvents(stream commands.EventStream, filter commands.EventFilter, eventChan chan string, errorChan chan error) {
+	errorChan <- stream.StreamTcp(func(e routing_api.TcpEvent) {
+		if !filter.MatchTcp(e) {
+			return
+		}
 		event, _ := json.Marshal(e)
 		eventChan <- fmt.Sprintf("%v\n", string(event))
+	})
+}
+
+// eventFilter builds the filter of the events command from its flags. The
+// router groups are resolved separately, since that needs the routing-api.
+func eventFilter(c *cli.Context) (commands.EventFilter, []string) {
+	var issues []string
+	filter := commands.EventFilter{
+		Ports:             c.IntSlice("port"),
+		IsolationSegments: c.StringSlice("isolation-segment"),
+	}
+
+	for _, action := range c.StringSlice("action") {
+		if !strings.EqualFold(action, "upsert") && !strings.EqualFold(action, "delete") {
+			issues = append(issues, fmt.Sprintf("Invalid action: %s, must be upsert or delete.", action))
+		}
+		filter.Actions = append(filter.Actions, action)
+	}
+
+	for _, route := range c.StringSlice("route") {
+		pattern, err := commands.ParseRoutePattern(route)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("Invalid route pattern: %s: %s", route, err))
+			continue
+		}
+		filter.Routes = append(filter.Routes, pattern)
 	}
+
+	for _, ip := range c.StringSlice("ip") {
+		network, err := commands.ParseNetwork(ip)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("Invalid IP or CIDR: %s", ip))
+			continue
+		}
+		filter.Networks = append(filter.Networks, network)
+	}
+
+	for _, port := range filter.Ports {
+		if port < 1 || port > math.MaxUint16 {
+			issues = append(issues, fmt.Sprintf("Invalid port: %d", port))
+		}
+	}
+
+	return filter, issues
 }
 
-func streamTcpEvents(client routing_api.Client, eventChan chan string, errorChan chan error) {
-	eventSource, err := client.SubscribeToTcpEvents()
-	if err != nil {
-		fmt.Println("streaming events failed:", err)
-		return
+func listOptions(c *cli.Context) (commands.ListOptions, []string) {
+	var issues []string
+	options := commands.ListOptions{
+		Filter: commands.RouteFilter{
+			Ports:           c.IntSlice("port"),
+			LogGuids:        c.StringSlice("log-guid"),
+			HasRouteService: c.Bool("has-route-service"),
+		},
+		SortBy: c.String("sort-by"),
+		Limit:  c.Int("limit"),
 	}
-	for {
-		e, err := eventSource.Next()
+
+	for _, route := range c.StringSlice("route") {
+		pattern, err := commands.ParseRoutePattern(route)
 		if err != nil {
-			errorChan <- err
-			break
+			issues = append(issues, fmt.Sprintf("Invalid route pattern: %s: %s", route, err))
+			continue
 		}
+		options.Filter.Routes = append(options.Filter.Routes, pattern)
+	}
 
-		event, _ := json.Marshal(e)
-		eventChan <- fmt.Sprintf("%v\n", string(event))
+	for _, ip := range c.StringSlice("ip") {
+		network, err := commands.ParseNetwork(ip)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("Invalid IP or CIDR: %s", ip))
+			continue
+		}
+		options.Filter.Networks = append(options.Filter.Networks, network)
+	}
+
+	for _, port := range options.Filter.Ports {
+		if port < 1 || port > math.MaxUint16 {
+			issues = append(issues, fmt.Sprintf("Invalid port: %d", port))
+		}
+	}
+
+	if options.SortBy != "" && !contains(commands.RouteSortFields, options.SortBy) {
+		issues = append(issues, fmt.Sprintf("Invalid sort field: %s, must be one of %s.", options.SortBy, strings.Join(commands.RouteSortFields, ", ")))
+	}
+
+	if options.Limit < 0 {
+		issues = append(issues, "Limit must not be negative.")
+	}
+
+	if field := c.String("group-by"); field != "" {
+		if !contains(commands.RouteGroupFields, field) {
+			issues = append(issues, fmt.Sprintf("Invalid group field: %s, must be one of %s.", field, strings.Join(commands.RouteGroupFields, ", ")))
+		}
+		if options.SortBy != "" {
+			issues = append(issues, "Must not provide --sort-by with --group-by, groups are sorted by their number of routes.")
+		}
 	}
+
+	return options, issues
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// routerGroupGuids replaces the router group names among values with their
+// guids. Values that are not the name of a router group are taken as guids.
+func routerGroupGuids(client routing_api.Client, values []string) ([]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	groups, err := client.RouterGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	guids := make([]string, 0, len(values))
+	for _, value := range values {
+		guid := value
+		for _, group := range groups {
+			if group.Name == value {
+				guid = group.Guid
+				break
+			}
+		}
+		guids = append(guids, guid)
+	}
+	return guids, nil
 }
 
 func checkFlags(c *cli.Context) []string {
+	conn, err := resolveConnection(c)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	return checkConnection(conn)
+}
+
+func checkConnection(conn connection) []string {
 	var issues []string
 
-	if c.String("api") == "" {
+	if conn.api == "" {
 		issues = append(issues, "Must provide an API endpoint for the routing-api component.")
 	}
 
-	if c.String("client-id") == "" {
+	if conn.clientID == "" {
 		issues = append(issues, "Must provide the id of an OAuth client.")
 	}
 
-	if c.String("client-secret") == "" {
+	if conn.clientSecret == "" {
 		issues = append(issues, "Must provide an OAuth secret.")
 	}
 
-	if c.String("oauth-url") == "" {
+	if conn.oauthURL == "" {
 		issues = append(issues, "Must provide an URL to the OAuth client.")
 	}
 
-	_, err := url.Parse(c.String("oauth-url"))
+	_, err := url.Parse(conn.oauthURL)
 	if err != nil {
 		issues = append(issues, "Invalid OAuth client URL")
 	}
@@ -319,20 +837,186 @@ func checkArguments(c *cli.Context, cmd string) []string {
 
 	switch cmd {
 	case "register", "unregister":
+		sources := len(c.Args())
+		if len(c.StringSlice("file")) > 0 {
+			sources++
+		}
+		if routeFlagsGiven(c) {
+			sources++
+		}
+
+		if len(c.Args()) > 1 {
+			issues = append(issues, "Unexpected arguments.")
+		} else if sources > 1 {
+			issues = append(issues, "Must provide routes either as JSON, with --file or with --route, not several.")
+		} else if sources < 1 {
+			issues = append(issues, "Must provide routes JSON, --file or --route.")
+		} else if routeFlagsGiven(c) {
+			issues = append(issues, checkRouteFlags(c)...)
+		}
+	case "tcp-register", "tcp-unregister":
 		if len(c.Args()) > 1 {
 			issues = append(issues, "Unexpected arguments.")
 		} else if len(c.Args()) < 1 {
-			issues = append(issues, "Must provide routes JSON.")
+			issues = append(issues, "Must provide TCP route mappings JSON.")
 		}
-	case "list", "events":
+	case "plan":
 		if len(c.Args()) > 0 {
 			issues = append(issues, "Unexpected arguments.")
 		}
+		if len(c.StringSlice("file")) == 0 {
+			issues = append(issues, "Must provide the desired routes with --file.")
+		}
+	case "apply":
+		if len(c.Args()) > 1 {
+			issues = append(issues, "Unexpected arguments.")
+		} else if len(c.Args()) == 1 && len(c.StringSlice("file")) > 0 {
+			issues = append(issues, "Must provide either a plan file or the desired routes with --file, not both.")
+		} else if len(c.Args()) == 1 {
+			for _, name := range planningFlagNames {
+				if c.IsSet(name) {
+					issues = append(issues, fmt.Sprintf("Cannot use --%s with a plan file, it only applies when planning.", name))
+				}
+			}
+		} else if len(c.Args()) == 0 && len(c.StringSlice("file")) == 0 {
+			issues = append(issues, "Must provide a plan file or the desired routes with --file.")
+		}
+	case "who-routes-to":
+		if len(c.Args()) > 1 {
+			issues = append(issues, "Unexpected arguments.")
+		} else if len(c.Args()) < 1 {
+			issues = append(issues, "Must provide a backend IP, IP and port, or CIDR.")
+		}
+	case "export", "import":
+		if len(c.Args()) > 1 {
+			issues = append(issues, "Unexpected arguments.")
+		} else if len(c.Args()) < 1 {
+			issues = append(issues, "Must provide an archive file.")
+		}
+	case "list", "events", "login", "logout", "migrate", "stats", "lint", "watch":
+		if len(c.Args()) > 0 {
+			issues = append(issues, "Unexpected arguments.")
+		}
+	case "get", "delete":
+		if len(c.Args()) > 1 {
+			issues = append(issues, "Unexpected arguments.")
+		} else if len(c.Args()) < 1 {
+			issues = append(issues, "Must provide a router group name.")
+		}
+	case "add", "use", "remove":
+		if len(c.Args()) > 1 {
+			issues = append(issues, "Unexpected arguments.")
+		} else if len(c.Args()) < 1 {
+			issues = append(issues, "Must provide a target name.")
+		}
+	case "create", "update":
+		if len(c.Args()) > 1 {
+			issues = append(issues, "Unexpected arguments.")
+		} else if len(c.Args()) < 1 {
+			issues = append(issues, "Must provide router group JSON.")
+		}
 	}
 
 	return issues
 }
 
+// routeFlagsGiven reports whether a route is given with the route flags.
+func routeFlagsGiven(c *cli.Context) bool {
+	for _, name := range routeFlagNames {
+		if c.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkRouteFlags(c *cli.Context) []string {
+	var issues []string
+
+	if c.String("route") == "" {
+		issues = append(issues, "Must provide --route with the other route flags.")
+	}
+
+	if len(c.StringSlice("backend")) > 0 && (c.IsSet("ip") || c.IsSet("port")) {
+		issues = append(issues, "Must provide backends either with --backend or with --ip and --port, not both.")
+	}
+
+	if c.Int("port") < 0 || c.Int("port") > math.MaxUint16 {
+		issues = append(issues, fmt.Sprintf("Invalid port: %d", c.Int("port")))
+	}
+
+	return issues
+}
+
+func checkStreamArguments(c *cli.Context) []string {
+	var issues []string
+
+	if len(c.Args()) > 0 || len(c.StringSlice("file")) > 0 || routeFlagsGiven(c) {
+		issues = append(issues, "--stream reads routes from stdin, must not provide routes JSON, --file or --route.")
+	}
+
+	if c.String("input-format") != "" && c.String("input-format") != input.JSON {
+		issues = append(issues, "--stream only reads newline-delimited JSON.")
+	}
+
+	if c.Bool("dry-run") {
+		issues = append(issues, "--dry-run cannot be used with --stream.")
+	}
+
+	if c.Int("batch-size") < 1 {
+		issues = append(issues, "Batch size must be at least 1.")
+	}
+
+	if c.Duration("flush-interval") <= 0 {
+		issues = append(issues, "Flush interval must be positive.")
+	}
+
+	return issues
+}
+
+func checkRetryFlags(c *cli.Context) []string {
+	var issues []string
+
+	if c.Int("max-retries") < 0 || c.Int("max-retries") > math.MaxUint16 {
+		issues = append(issues, fmt.Sprintf("Max retries must be between 0 and %d.", math.MaxUint16))
+	}
+
+	if c.Duration("retry-backoff") < 0 {
+		issues = append(issues, "Retry backoff must not be negative.")
+	} else if c.Duration("retry-backoff") == 0 && c.Bool("forever") {
+		issues = append(issues, "Retry backoff must be positive when retrying forever.")
+	}
+
+	return issues
+}
+
+func checkInputFormat(c *cli.Context) []string {
+	var issues []string
+
+	err := input.ValidateFormat(c.String("input-format"))
+	if err != nil {
+		issues = append(issues, fmt.Sprintf("Invalid input format: %s: %s", c.String("input-format"), err))
+	}
+
+	return issues
+}
+
+func checkOutputFormat(c *cli.Context) []string {
+	var issues []string
+
+	err := output.ValidateFormat(c.String("output"))
+	if err != nil {
+		issues = append(issues, fmt.Sprintf("Invalid output format: %s: %s", c.String("output"), err))
+	}
+
+	return issues
+}
+
+func printRecords(c *cli.Context, errorMessage string, records output.Tabular) {
+	err := output.Write(os.Stdout, c.String("output"), records)
+	checkError(errorMessage, err)
+}
+
 func printHelpForCommand(c *cli.Context, issues []string, cmd string) {
 	for _, issue := range issues {
 		fmt.Println(issue)
@@ -351,6 +1035,25 @@ func commandNotFound(c *cli.Context, cmd string) {
 }
 
 func newRoutingApiClient(c *cli.Context) (routing_api.Client, error) {
+	conn, err := resolveConnection(c)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn)
+}
+
+func newClient(conn connection) (routing_api.Client, error) {
+	token, err := accessToken(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	routingApiClient := routing_api.NewClient(conn.api, conn.skipTLSVerification)
+	routingApiClient.SetToken(token.AccessToken)
+	return routingApiClient, nil
+}
+
+func newTokenFetcher(conn connection) (uaaclient.TokenFetcher, error) {
 	rtr_trace := os.Getenv(RTR_TRACE)
 	var logger lager.Logger
 	if rtr_trace == "true" {
@@ -359,8 +1062,8 @@ func newRoutingApiClient(c *cli.Context) (routing_api.Client, error) {
 		logger = lager.NewLogger("rtr")
 	}
 
-	tokenURL := c.String("oauth-url")
-	u, err := url.Parse(c.String("oauth-url"))
+	tokenURL := conn.oauthURL
+	u, err := url.Parse(conn.oauthURL)
 	if err != nil {
 		return nil, err
 	}
@@ -374,29 +1077,16 @@ func newRoutingApiClient(c *cli.Context) (routing_api.Client, error) {
 	}
 
 	uaaConfig := uaaclient.Config{
-		SkipSSLValidation: c.Bool("skip-tls-verification"),
-		ClientName:        c.String("client-id"),
-		ClientSecret:      c.String("client-secret"),
-		CACerts:           c.String("ca-certs"),
+		SkipSSLValidation: conn.skipTLSVerification,
+		ClientName:        conn.clientID,
+		ClientSecret:      conn.clientSecret,
+		CACerts:           conn.caCerts,
 		TokenEndpoint:     addr[0],
 		Port:              uint16(port),
 	}
 
 	clk := clock.NewClock()
-	uaaClient, err := uaaclient.NewTokenFetcher(false, uaaConfig, clk, 3, 500*time.Millisecond, 30, logger)
-	if err != nil {
-		return nil, err
-	}
-
-	token, err := uaaClient.FetchToken(context.Background(), true)
-	if err != nil {
-		return nil, err
-	}
-
-	routingApiClient := routing_api.NewClient(c.String("api"), c.Bool("skip-tls-verification"))
-	routingApiClient.SetToken(token.AccessToken)
-	return routingApiClient, nil
-
+	return uaaclient.NewTokenFetcher(false, uaaConfig, clk, 3, 500*time.Millisecond, DefaultExpirationBufferTime, logger)
 }
 
 func checkError(message string, err error) {
@@ -6,6 +6,8 @@ import (
 	"encoding/pem"
 	"net/http"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"os"
@@ -22,9 +24,22 @@ import (
 
 var _ = Describe("Main", func() {
 	var (
-		flags []string
+		flags     []string
+		configDir string
 	)
 
+	BeforeEach(func() {
+		var err error
+		configDir, err = os.MkdirTemp("", "routing-api-cli-config")
+		Expect(err).ToNot(HaveOccurred())
+		os.Setenv("RTR_CONFIG", filepath.Join(configDir, "config.yml"))
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("RTR_CONFIG")
+		Expect(os.RemoveAll(configDir)).To(Succeed())
+	})
+
 	var buildCommand = func(cmd string, flags []string, args []string) []string {
 		command := []string{cmd}
 		command = append(command, flags...)
@@ -102,7 +117,7 @@ var _ = Describe("Main", func() {
 				),
 			)
 
-			command := buildCommand("register", flags, []string{"[{}]"})
+			command := buildCommand("register", flags, []string{`[{"route":"zak.com","port":8080,"ip":"1.2.3.4","ttl":60}]`})
 			session := routingAPICLI(command...)
 
 			Eventually(session, "2s").Should(Exit(0))
@@ -110,7 +125,7 @@ var _ = Describe("Main", func() {
 		})
 
 		It("registers a route to the routing api", func() {
-			command := buildCommand("register", flags, []string{`[{"route":"zak.com","port":3,"ip":"4","ttl":1}]`})
+			command := buildCommand("register", flags, []string{"--no-validate", `[{"route":"zak.com","port":3,"ip":"4","ttl":1}]`})
 
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
@@ -140,7 +155,7 @@ var _ = Describe("Main", func() {
 
 		It("registers multiple routes to the routing api", func() {
 			routes := `[{"route":"zak.com","port":0,"ip": "","ttl":5,"log_guid":"yo"},{"route":"jak.com","port":8,"ip":"11","ttl":0}]`
-			command := buildCommand("register", flags, []string{routes})
+			command := buildCommand("register", flags, []string{"--no-validate", routes})
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
 					ghttp.VerifyRequest("POST", "/routing/v1/routes"),
@@ -179,9 +194,173 @@ var _ = Describe("Main", func() {
 			Expect(server.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		Context("with --file", func() {
+			var routesFile string
+
+			BeforeEach(func() {
+				f, err := os.CreateTemp("", "routing-api-cli-routes")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = f.WriteString(`[{"route":"zak.com","port":3,"ip":"4","ttl":1}]`)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(f.Close()).To(Succeed())
+				routesFile = f.Name()
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/routing/v1/routes"),
+						ghttp.VerifyJSONRepresenting([]map[string]interface{}{
+							{"route": "zak.com", "port": 3, "ip": "4", "ttl": 1, "log_guid": "", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+							{"route": "jak.com", "port": 8, "ip": "11", "ttl": 2, "log_guid": "", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+						}),
+						ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+					),
+				)
+			})
+
+			AfterEach(func() {
+				Expect(os.Remove(routesFile)).To(Succeed())
+			})
+
+			It("registers the routes of the files and stdin", func() {
+				command := buildCommand("register", flags, []string{"--no-validate", "-f", routesFile, "-f", "-"})
+				cmd := exec.Command(path, command...)
+				cmd.Stdin = strings.NewReader(`[{"route":"jak.com","port":8,"ip":"11","ttl":2}]`)
+				session, err := Start(cmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(string(session.Out.Contents())).To(ContainSubstring("Successfully registered routes: 2 routes from " + routesFile + ", -\n"))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		It("registers routes from YAML", func() {
+			command := buildCommand("register", flags, []string{"--no-validate", "--input-format", "yaml", "- {route: zak.com, port: 3, ip: '4', ttl: 1}"})
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/routing/v1/routes"),
+					ghttp.VerifyJSONRepresenting([]map[string]interface{}{
+						{"route": "zak.com", "port": 3, "ip": "4", "ttl": 1, "log_guid": "", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+					}),
+					ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+				),
+			)
+
+			session := routingAPICLI(command...)
+
+			Eventually(session, "2s").Should(Exit(0))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("registers newline-delimited routes from stdin in batches", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/routing/v1/routes"),
+					ghttp.VerifyJSONRepresenting([]map[string]interface{}{
+						{"route": "a.com", "port": 1, "ip": "1.1.1.1", "ttl": 60, "log_guid": "", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+						{"route": "b.com", "port": 2, "ip": "1.1.1.1", "ttl": 60, "log_guid": "", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+					}),
+					ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/routing/v1/routes"),
+					ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+				),
+			)
+
+			command := buildCommand("register", flags, []string{"--stream", "--batch-size", "2"})
+			cmd := exec.Command(path, command...)
+			cmd.Stdin = strings.NewReader(`{"route":"a.com","port":1,"ip":"1.1.1.1","ttl":60}
+{"route":"b.com","port":2,"ip":"1.1.1.1","ttl":60}
+{"route":"c.com","port":3,"ip":"1.1.1.1","ttl":60}
+`)
+			session, err := Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(session, "2s").Should(Exit(0))
+			Expect(session.Out).To(Say("Batch 1: registered 2 routes"))
+			Expect(session.Out).To(Say("Batch 2: registered 1 routes"))
+			Expect(session.Out).To(Say("Registered 3 routes in 2 batches, 0 routes failed, 0 lines invalid"))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("registers a route built from flags for every backend", func() {
+			command := buildCommand("register", flags, []string{"--route", "zak.com", "--backend", "1.2.3.4:8080", "--backend", "1.2.3.5:8081", "--ttl", "60", "--log-guid", "yo"})
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/routing/v1/routes"),
+					ghttp.VerifyJSONRepresenting([]map[string]interface{}{
+						{"route": "zak.com", "port": 8080, "ip": "1.2.3.4", "ttl": 60, "log_guid": "yo", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+						{"route": "zak.com", "port": 8081, "ip": "1.2.3.5", "ttl": 60, "log_guid": "yo", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+					}),
+					ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+				),
+			)
+
+			session := routingAPICLI(command...)
+
+			Eventually(session, "2s").Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(ContainSubstring(`Successfully registered routes: [{"route":"zak.com","port":8080,"ip":"1.2.3.4"`))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("unregisters a route built from flags", func() {
+			command := buildCommand("unregister", flags, []string{"--route", "zak.com", "--ip", "1.2.3.4", "--port", "8080", "--ttl", "5"})
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/routing/v1/routes"),
+					ghttp.VerifyJSONRepresenting([]map[string]interface{}{
+						{"route": "zak.com", "port": 8080, "ip": "1.2.3.4", "ttl": 5, "log_guid": "", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+					}),
+					ghttp.RespondWithJSONEncoded(http.StatusNoContent, nil),
+				),
+			)
+
+			session := routingAPICLI(command...)
+
+			Eventually(session, "2s").Should(Exit(0))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		Context("with --dry-run", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+							models.NewRoute("zak.com", 8080, "1.2.3.4", "", "", 60),
+						}),
+					),
+				)
+			})
+
+			It("shows what registering would change without registering", func() {
+				command := buildCommand("register", flags, []string{"--dry-run", `[{"route":"zak.com","port":8080,"ip":"1.2.3.4","ttl":120},{"route":"jak.com","port":8080,"ip":"1.2.3.4","ttl":60}]`})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say(`update\s+zak.com\s+1.2.3.4\s+8080\s+120\s+60`))
+				Expect(session.Out).To(Say(`create\s+jak.com`))
+				Expect(session.Out).To(Say("Dry run: would create 1 routes, update 1 routes and leave 0 routes unchanged"))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+
+			It("shows which deletes would be no-ops without unregistering", func() {
+				command := buildCommand("unregister", flags, []string{"--dry-run", `[{"route":"zak.com","port":8080,"ip":"1.2.3.4"},{"route":"jak.com","port":8080,"ip":"1.2.3.4"}]`})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say("Dry run: would delete 1 routes, 1 deletes would be no-ops"))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
 		It("Unregisters a route to the routing api", func() {
 			routes := `[{"route":"zak.com","ttl":5,"log_guid":"yo"}]`
-			command := buildCommand("unregister", flags, []string{routes})
+			command := buildCommand("unregister", flags, []string{"--no-validate", routes})
 
 			server.AppendHandlers(
 				ghttp.CombineHandlers(
@@ -203,69 +382,818 @@ var _ = Describe("Main", func() {
 				),
 			)
 
-			session := routingAPICLI(command...)
+			session := routingAPICLI(command...)
+
+			Eventually(session, "2s").Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(ContainSubstring("Successfully unregistered routes: " + routes))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		Describe("Listing routes", func() {
+			BeforeEach(func() {
+				os.Unsetenv("RTR_TRACE")
+			})
+			It("Lists the routes without logging uaa messages", func() {
+				routes := []models.Route{
+					models.NewRoute("llama.example.com", 0, "", "yo", "", 5),
+					models.NewRoute("example.com", 8, "11", "yo", "", 1),
+				}
+				command := buildCommand("list", flags, []string{})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				expectedRoutes, err := json.Marshal(routes)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(string(session.Out.Contents())).ToNot(ContainSubstring("uaa-client"))
+				Expect(string(session.Out.Contents())).To(ContainSubstring(string(expectedRoutes) + "\n"))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+
+			It("lists the routes as a table", func() {
+				routes := []models.Route{
+					models.NewRoute("llama.example.com", 0, "", "yo", "", 5),
+					models.NewRoute("example.com", 8, "11", "yo", "", 1),
+				}
+				command := buildCommand("list", flags, []string{"--output", "table"})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say(`ROUTE\s+IP\s+PORT\s+TTL\s+LOG_GUID\s+ROUTE_SERVICE_URL\s+MODIFICATION_GUID\s+MODIFICATION_INDEX\n`))
+				Expect(session.Out).To(Say(`llama.example.com\s+0\s+5\s+yo\s+0\n`))
+				Expect(session.Out).To(Say(`example.com\s+11\s+8\s+1\s+yo\s+0\n`))
+			})
+
+			It("lists the routes through a jsonpath template", func() {
+				routes := []models.Route{
+					models.NewRoute("llama.example.com", 0, "", "yo", "", 5),
+					models.NewRoute("example.com", 8, "11", "yo", "", 1),
+				}
+				command := buildCommand("list", flags, []string{"--output", `jsonpath={range [*]}{.route}{"\n"}{end}`})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(string(session.Out.Contents())).To(HaveSuffix("llama.example.com\nexample.com\n"))
+			})
+
+			It("filters, sorts and limits the routes", func() {
+				routes := []models.Route{
+					models.NewRoute("llama.example.com", 8080, "10.0.0.2", "yo", "", 5),
+					models.NewRoute("alpaca.example.com", 8080, "10.0.0.1", "yo", "", 5),
+					models.NewRoute("camel.example.com", 8080, "10.0.0.3", "yo", "", 5),
+					models.NewRoute("example.org", 8080, "10.0.0.4", "yo", "", 5),
+				}
+				command := buildCommand("list", flags, []string{"--route", "*.example.com", "--ip", "10.0.0.0/24", "--sort-by", "ip", "--limit", "2", "--output", `jsonpath={range [*]}{.route}{"\n"}{end}`})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(string(session.Out.Contents())).To(HaveSuffix("\nalpaca.example.com\nllama.example.com\n"))
+			})
+
+			It("groups the routes", func() {
+				routes := []models.Route{
+					models.NewRoute("llama.example.com", 8080, "10.0.0.1", "yo", "", 5),
+					models.NewRoute("llama.example.com", 8080, "10.0.0.2", "yo", "", 5),
+					models.NewRoute("alpaca.example.com", 8080, "10.0.0.1", "yo", "", 5),
+				}
+				command := buildCommand("list", flags, []string{"--group-by", "route", "--output", "table"})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say(`KEY\s+ROUTES\s+HOSTS\s+BACKENDS\n`))
+				Expect(session.Out).To(Say(`llama.example.com\s+2\s+1\s+2\n`))
+				Expect(session.Out).To(Say(`alpaca.example.com\s+1\s+1\s+1\n`))
+			})
+
+			It("summarizes the routes", func() {
+				server.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+					models.NewRoute("llama.example.com", 8080, "10.0.0.1", "yo", "", 5),
+					models.NewRoute("llama.example.com", 8080, "10.0.0.2", "yo", "https://rs.example.com", 120),
+				}))
+				server.RouteToHandler("GET", "/routing/v1/tcp_routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.TcpRouteMapping{}))
+
+				session := routingAPICLI(buildCommand("stats", flags, []string{"--output", "table"})...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say(`STAT\s+VALUE\n`))
+				Expect(session.Out).To(Say(`routes\s+2\n`))
+				Expect(session.Out).To(Say(`tcp_routes\s+0\n`))
+				Expect(session.Out).To(Say(`unique_hosts\s+1\n`))
+				Expect(session.Out).To(Say(`unique_backend_ips\s+2\n`))
+				Expect(session.Out).To(Say(`route_service_routes\s+1\n`))
+				Expect(session.Out).To(Say(`ttl <= 30s\s+1\n`))
+				Expect(session.Out).To(Say(`ttl <= 2m\s+1\n`))
+				Expect(session.Out).To(Say(`top_host llama.example.com\s+2 backends, 2 routes\n`))
+			})
+
+			It("prints the stats as JSON by default", func() {
+				server.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+					models.NewRoute("llama.example.com", 8080, "10.0.0.1", "yo", "", 5),
+				}))
+				server.RouteToHandler("GET", "/routing/v1/tcp_routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.TcpRouteMapping{}))
+
+				session := routingAPICLI(buildCommand("stats", flags, []string{})...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say(`\{"routes":1,"tcp_routes":0,"unique_hosts":1,"unique_backend_ips":1,"route_service_routes":0,"ttl_histogram":\[\{"ttl":"<= 30s","routes":1\}`))
+			})
+
+			It("rejects unknown sort fields", func() {
+				command := buildCommand("list", flags, []string{"--sort-by", "color"})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(1))
+				Expect(session.Out).To(Say("Invalid sort field: color, must be one of route, ip, port, ttl, log_guid, route_service_url."))
+			})
+
+			Context("with RTR_TRACE=true", func() {
+				BeforeEach(func() {
+					os.Setenv("RTR_TRACE", "true")
+				})
+				It("Lists the routes and logs uaa messages", func() {
+					routes := []models.Route{
+						models.NewRoute("llama.example.com", 0, "", "yo", "", 5),
+						models.NewRoute("example.com", 8, "11", "yo", "", 1),
+					}
+					command := buildCommand("list", flags, []string{})
+
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+							ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
+						),
+					)
+
+					session := routingAPICLI(command...)
+
+					expectedRoutes, err := json.Marshal(routes)
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(session, "2s").Should(Exit(0))
+					Expect(string(session.Out.Contents())).To(ContainSubstring(string(expectedRoutes) + "\n"))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+				})
+			})
+		})
+
+		Describe("plan and apply", func() {
+			var desiredFile string
+
+			BeforeEach(func() {
+				f, err := os.CreateTemp("", "routing-api-cli-desired-*.yml")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = f.WriteString(`
+routes:
+- {route: zak.com, port: 8080, ip: 1.2.3.4, ttl: 120, log_guid: team-a}
+- {route: jak.com, port: 8080, ip: 1.2.3.4, ttl: 60, log_guid: team-a}
+`)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(f.Close()).To(Succeed())
+				desiredFile = f.Name()
+
+				server.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+					models.NewRoute("zak.com", 8080, "1.2.3.4", "team-a", "", 60),
+					models.NewRoute("old.com", 8080, "1.2.3.4", "team-a", "", 60),
+					models.NewRoute("other.com", 8080, "1.2.3.4", "team-b", "", 60),
+				}))
+				server.RouteToHandler("GET", "/routing/v1/tcp_routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.TcpRouteMapping{}))
+			})
+
+			AfterEach(func() {
+				Expect(os.Remove(desiredFile)).To(Succeed())
+			})
+
+			It("shows the changes without applying them", func() {
+				command := buildCommand("plan", flags, []string{"-f", desiredFile, "--prune", "--scope-log-guid", "team-a"})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say(`~ route zak.com -> 1.2.3.4:8080 \(ttl 60 -> 120\)`))
+				Expect(session.Out).To(Say(`\+ route jak.com -> 1.2.3.4:8080`))
+				Expect(session.Out).To(Say(`- route old.com -> 1.2.3.4:8080`))
+				Expect(session.Out).To(Say("Plan: 1 to create, 1 to update, 1 to delete, 0 unchanged"))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring("other.com"))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring("\033["))
+			})
+
+			It("applies the changes", func() {
+				server.RouteToHandler("POST", "/routing/v1/routes", ghttp.CombineHandlers(
+					ghttp.VerifyJSONRepresenting([]map[string]interface{}{
+						{"route": "zak.com", "port": 8080, "ip": "1.2.3.4", "ttl": 120, "log_guid": "team-a", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+						{"route": "jak.com", "port": 8080, "ip": "1.2.3.4", "ttl": 60, "log_guid": "team-a", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+					}),
+					ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+				))
+				server.RouteToHandler("DELETE", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusNoContent, nil))
+
+				command := buildCommand("apply", flags, []string{"-f", desiredFile, "--prune", "--scope-log-guid", "team-a"})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say("Successfully applied the changes"))
+				Expect(server.ReceivedRequests()).To(HaveLen(4))
+			})
+
+			It("refuses to prune without a scope", func() {
+				command := buildCommand("apply", flags, []string{"-f", desiredFile, "--prune"})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(3))
+				Expect(session.Out).To(Say("applying routes failed: pruning requires a scope"))
+			})
+
+			Context("with a saved plan", func() {
+				var planFile string
+
+				BeforeEach(func() {
+					planFile = desiredFile + ".plan"
+					command := buildCommand("plan", flags, []string{"-f", desiredFile, "--prune", "--scope-log-guid", "team-a", "--out", planFile})
+					session := routingAPICLI(command...)
+
+					Eventually(session, "2s").Should(Exit(0))
+					Expect(session.Out).To(Say("Saved the plan to " + planFile))
+				})
+
+				AfterEach(func() {
+					Expect(os.Remove(planFile)).To(Succeed())
+				})
+
+				It("applies the saved changes", func() {
+					server.RouteToHandler("POST", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusCreated, nil))
+					server.RouteToHandler("DELETE", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusNoContent, nil))
+
+					command := buildCommand("apply", flags, []string{planFile})
+					session := routingAPICLI(command...)
+
+					Eventually(session, "2s").Should(Exit(0))
+					Expect(session.Out).To(Say("Plan: 1 to create, 1 to update, 1 to delete, 0 unchanged"))
+					Expect(session.Out).To(Say("Successfully applied the changes"))
+				})
+
+				It("refuses to apply the plan when the routes changed since", func() {
+					zak := models.NewRoute("zak.com", 8080, "1.2.3.4", "team-a", "", 60)
+					zak.ModificationTag = models.ModificationTag{Guid: "some-guid", Index: 1}
+					server.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+						zak,
+						models.NewRoute("jak.com", 8080, "1.2.3.4", "team-a", "", 60),
+						models.NewRoute("old.com", 8080, "1.2.3.4", "team-a", "", 60),
+					}))
+
+					command := buildCommand("apply", flags, []string{planFile})
+					session := routingAPICLI(command...)
+
+					Eventually(session, "2s").Should(Exit(3))
+					Expect(session.Out).To(Say("applying routes failed: " + planFile + " is stale, plan again: the routes changed since the plan was made:"))
+					Expect(session.Out).To(Say("route zak.com 1.2.3.4:8080 was modified"))
+					Expect(session.Out).To(Say("route jak.com 1.2.3.4:8080 was registered"))
+				})
+
+				It("refuses a plan file together with --file", func() {
+					command := buildCommand("apply", flags, []string{"-f", desiredFile, planFile})
+					session := routingAPICLI(command...)
+
+					Eventually(session, "2s").Should(Exit(1))
+					Expect(session.Out).To(Say("Must provide either a plan file or the desired routes with --file, not both."))
+				})
+
+				It("refuses a plan file together with the flags for planning", func() {
+					requests := len(server.ReceivedRequests())
+					command := buildCommand("apply", flags, []string{"--prune", "--scope-route", "*.example.com", "--no-validate", planFile})
+					session := routingAPICLI(command...)
+
+					Eventually(session, "2s").Should(Exit(1))
+					Expect(session.Out).To(Say("Cannot use --prune with a plan file, it only applies when planning."))
+					Expect(session.Out).To(Say("Cannot use --scope-route with a plan file, it only applies when planning."))
+					Expect(session.Out).To(Say("Cannot use --no-validate with a plan file, it only applies when planning."))
+					Expect(server.ReceivedRequests()).To(HaveLen(requests))
+				})
+			})
+		})
+
+		Describe("export and import", func() {
+			var archiveFile string
+
+			BeforeEach(func() {
+				archiveFile = filepath.Join(GinkgoT().TempDir(), "routes.json.gz")
+
+				server.RouteToHandler("GET", "/routing/v1/router_groups", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.RouterGroup{
+					{Guid: "some-guid", Name: "default-tcp", Type: "tcp", ReservablePorts: "1024-1033"},
+				}))
+				server.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+					models.NewRoute("zak.com", 8080, "1.2.3.4", "team-a", "", 60),
+				}))
+				server.RouteToHandler("GET", "/routing/v1/tcp_routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.TcpRouteMapping{
+					models.NewTcpRouteMapping("some-guid", 1024, "1.2.3.4", 61000, 0, "", nil, 60, models.ModificationTag{}),
+				}))
+
+				command := buildCommand("export", flags, []string{archiveFile})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say("Successfully exported 1 router groups, 1 routes and 1 tcp routes to " + archiveFile))
+			})
+
+			It("restores the routes with the TTL override", func() {
+				server.RouteToHandler("POST", "/routing/v1/routes", ghttp.CombineHandlers(
+					ghttp.VerifyJSONRepresenting([]map[string]interface{}{
+						{"route": "zak.com", "port": 8080, "ip": "1.2.3.4", "ttl": 3600, "log_guid": "team-a", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+					}),
+					ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+				))
+
+				command := buildCommand("import", flags, []string{"--only", "http", "--ttl-override", "3600", archiveFile})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say("Importing the archive exported from " + server.URL()))
+				Expect(session.Out).To(Say("Successfully imported 1 routes and 0 tcp routes, created 0 and updated 0 router groups"))
+			})
+
+			It("rejects unknown parts", func() {
+				command := buildCommand("import", flags, []string{"--only", "udp", archiveFile})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(1))
+				Expect(session.Out).To(Say("Invalid --only: udp, must be one of http, tcp, router-groups."))
+			})
+		})
+
+		Describe("migrate", func() {
+			var newServer *ghttp.Server
+
+			BeforeEach(func() {
+				newServer = ghttp.NewServer()
+
+				session := routingAPICLI(buildCommand("target", append([]string{"add"}, flags...), []string{"old"})...)
+				Eventually(session).Should(Exit(0))
+				newFlags := append([]string{}, flags...)
+				newFlags[1] = newServer.URL()
+				session = routingAPICLI(buildCommand("target", append([]string{"add"}, newFlags...), []string{"new"})...)
+				Eventually(session).Should(Exit(0))
+
+				server.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+					models.NewRoute("zak.com", 8080, "10.0.1.5", "team-a", "", 60),
+					models.NewRoute("other.com", 8080, "10.0.1.6", "team-b", "", 60),
+				}))
+				server.RouteToHandler("GET", "/routing/v1/tcp_routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.TcpRouteMapping{}))
+				newServer.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{}))
+				newServer.RouteToHandler("GET", "/routing/v1/tcp_routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.TcpRouteMapping{}))
+			})
+
+			AfterEach(func() {
+				newServer.Close()
+			})
+
+			It("shows the routes it would copy", func() {
+				session := routingAPICLI("migrate", "--from", "old", "--to", "new", "--log-guid", "team-a", "--rewrite-ip", "10.0.0.0/16=10.1.0.0/16")
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say(`\+ route zak.com -> 10.1.1.5:8080`))
+				Expect(session.Out).To(Say("Plan: 1 to create, 0 to update, 0 to delete, 0 unchanged"))
+				Expect(session.Out).To(Say("Dry run: run again with --apply to copy the routes"))
+				Expect(newServer.ReceivedRequests()).To(HaveLen(2))
+			})
+
+			It("copies the routes with --apply", func() {
+				newServer.RouteToHandler("POST", "/routing/v1/routes", ghttp.CombineHandlers(
+					ghttp.VerifyJSONRepresenting([]map[string]interface{}{
+						{"route": "zak.com", "port": 8080, "ip": "10.1.1.5", "ttl": 60, "log_guid": "team-a", "modification_tag": map[string]interface{}{"guid": "", "index": 0}},
+					}),
+					ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+				))
+
+				session := routingAPICLI("migrate", "--from", "old", "--to", "new", "--route", "zak.com", "--rewrite-ip", "10.0.0.0/16=10.1.0.0/16", "--apply")
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say("Successfully copied the routes from old to new"))
+			})
+
+			It("rejects rewrite rules between networks of different sizes", func() {
+				session := routingAPICLI("migrate", "--from", "old", "--to", "new", "--rewrite-ip", "10.0.0.0/16=10.1.0.0/24")
+
+				Eventually(session, "2s").Should(Exit(1))
+				Expect(session.Out).To(Say("Invalid IP rewrite rule: 10.0.0.0/16 and 10.1.0.0/24 differ in size"))
+			})
+		})
+
+		Describe("who-routes-to", func() {
+			BeforeEach(func() {
+				server.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+					models.NewRoute("zak.com", 61000, "10.0.0.5", "app-zak", "", 60),
+					models.NewRoute("jak.com", 61000, "10.0.1.5", "app-jak", "", 60),
+				}))
+				server.RouteToHandler("GET", "/routing/v1/tcp_routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.TcpRouteMapping{
+					models.NewTcpRouteMapping("some-guid", 1024, "10.0.0.5", 61001, 0, "", nil, 60, models.ModificationTag{}),
+				}))
+				server.RouteToHandler("GET", "/routing/v1/router_groups", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.RouterGroup{
+					{Guid: "some-guid", Name: "default-tcp", Type: "tcp", ReservablePorts: "1024-1033"},
+				}))
+			})
+
+			It("lists the routes to the backends in the CIDR", func() {
+				command := buildCommand("who-routes-to", flags, []string{"--output", "table", "10.0.0.0/24"})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say(`TYPE\s+BACKEND\s+ROUTE\s+LOG_GUID\s+ROUTER_GROUP\s+PORT\n`))
+				Expect(session.Out).To(Say(`http\s+10.0.0.5:61000\s+zak.com\s+app-zak\s*\n`))
+				Expect(session.Out).To(Say(`tcp\s+10.0.0.5:61001\s+default-tcp\s+1024\n`))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring("jak.com"))
+			})
+
+			It("rejects invalid backends", func() {
+				command := buildCommand("who-routes-to", flags, []string{"cell-1"})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(1))
+				Expect(session.Out).To(Say("Invalid backend: cell-1: invalid IP address: cell-1"))
+			})
+		})
+
+		Describe("lint", func() {
+			BeforeEach(func() {
+				server.RouteToHandler("GET", "/routing/v1/tcp_routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.TcpRouteMapping{}))
+				server.RouteToHandler("GET", "/routing/v1/router_groups", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.RouterGroup{}))
+			})
+
+			It("passes when only warnings are found", func() {
+				server.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+					models.NewRoute("zak.com", 8080, "10.0.0.1", "app", "", 86400),
+				}))
+
+				session := routingAPICLI(buildCommand("lint", flags, []string{"--output", "table"})...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say(`SEVERITY\s+CHECK\s+SUBJECT\s+MESSAGE\n`))
+				Expect(session.Out).To(Say(`warning\s+ttl\s+zak.com 10.0.0.1:8080\s+ttl 86400s is longer than 3600s`))
+				Expect(session.Out).To(Say("Found 0 errors, 1 warnings and 0 infos"))
+			})
+
+			It("fails when errors are found", func() {
+				server.RouteToHandler("GET", "/routing/v1/routes", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.Route{
+					models.NewRoute("zak.com", 8080, "10.0.0.1", "app", "https://rs.example.com", 60),
+					models.NewRoute("zak.com", 8080, "10.0.0.2", "app", "", 60),
+				}))
+
+				session := routingAPICLI(buildCommand("lint", flags, []string{"--output", "json"})...)
+
+				Eventually(session, "2s").Should(Exit(3))
+				Expect(session.Out).To(Say(`"severity":"error","check":"route-service","subject":"zak.com"`))
+			})
+		})
+
+		Describe("router groups", func() {
+			It("lists the router groups", func() {
+				groups := []models.RouterGroup{
+					{Guid: "some-guid", Name: "default-tcp", Type: "tcp", ReservablePorts: "1024-1033"},
+				}
+				command := buildCommand("router-groups", append([]string{"list"}, flags...), []string{})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/router_groups"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, groups),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				expectedGroups, err := json.Marshal(groups)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(string(session.Out.Contents())).To(ContainSubstring(string(expectedGroups) + "\n"))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+
+			It("creates a router group", func() {
+				group := `{"name":"default-tcp","type":"tcp","reservable_ports":"1024-1033"}`
+				command := buildCommand("router-groups", append([]string{"create"}, flags...), []string{group})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/routing/v1/router_groups"),
+						ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(string(session.Out.Contents())).To(ContainSubstring("Successfully created router group: " + group))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+
+			It("rejects invalid reservable ports without creating the router group", func() {
+				command := buildCommand("router-groups", append([]string{"create"}, flags...), []string{`{"name":"default-tcp","type":"tcp","reservable_ports":"1024-abc"}`})
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(3))
+				Expect(string(session.Out.Contents())).To(ContainSubstring(`router group creation failed: invalid reservable_ports "1024-abc": "abc" is not a valid port`))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+
+		Describe("tcp routes", func() {
+			var mappings []models.TcpRouteMapping
+
+			BeforeEach(func() {
+				mappings = []models.TcpRouteMapping{
+					models.NewTcpRouteMapping("some-guid", 1234, "1.2.3.4", 6789, 0, "instance-guid", nil, 60, models.ModificationTag{}),
+				}
+			})
+
+			It("lists the tcp routes", func() {
+				command := buildCommand("tcp-routes", append([]string{"list"}, flags...), []string{})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/tcp_routes"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, mappings),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				expectedMappings, err := json.Marshal(mappings)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(string(session.Out.Contents())).To(ContainSubstring(string(expectedMappings) + "\n"))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+
+			It("lists the tcp routes of the given isolation segments", func() {
+				command := buildCommand("tcp-routes", append([]string{"list"}, flags...), []string{"--isolation-segment", "is1", "--isolation-segment", "is2"})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/tcp_routes", "isolation_segment=is1&isolation_segment=is2"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, mappings),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+
+			It("registers tcp routes", func() {
+				tcpRoutes := `[{"router_group_guid":"some-guid","port":1234,"backend_ip":"1.2.3.4","backend_port":6789,"ttl":60}]`
+				command := buildCommand("tcp-routes", append([]string{"register"}, flags...), []string{tcpRoutes})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/routing/v1/tcp_routes/create"),
+						ghttp.RespondWithJSONEncoded(http.StatusCreated, nil),
+					),
+				)
+
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(string(session.Out.Contents())).To(ContainSubstring("Successfully registered tcp routes: " + tcpRoutes))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+
+			It("unregisters tcp routes", func() {
+				tcpRoutes := `[{"router_group_guid":"some-guid","port":1234,"backend_ip":"1.2.3.4","backend_port":6789}]`
+				command := buildCommand("tcp-routes", append([]string{"unregister"}, flags...), []string{tcpRoutes})
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/routing/v1/tcp_routes/delete"),
+						ghttp.RespondWithJSONEncoded(http.StatusNoContent, nil),
+					),
+				)
 
-			Eventually(session, "2s").Should(Exit(0))
-			Expect(string(session.Out.Contents())).To(ContainSubstring("Successfully unregistered routes: " + routes))
-			Expect(server.ReceivedRequests()).To(HaveLen(1))
+				session := routingAPICLI(command...)
+
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(string(session.Out.Contents())).To(ContainSubstring("Successfully unregistered tcp routes: " + tcpRoutes))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
 		})
 
-		Describe("Listing routes", func() {
+		Describe("targets", func() {
+			var routes []models.Route
+
 			BeforeEach(func() {
-				os.Unsetenv("RTR_TRACE")
-			})
-			It("Lists the routes without logging uaa messages", func() {
-				routes := []models.Route{
+				routes = []models.Route{
 					models.NewRoute("llama.example.com", 0, "", "yo", "", 5),
-					models.NewRoute("example.com", 8, "11", "yo", "", 1),
 				}
-				command := buildCommand("list", flags, []string{})
+				session := routingAPICLI(buildCommand("target", append([]string{"add"}, flags...), []string{"local"})...)
+				Eventually(session).Should(Exit(0))
+				Expect(session.Out).To(Say("Successfully added target: local"))
+				Expect(session.Out).To(Say("Current target: local"))
+			})
+
+			It("stores the targets in a file only the owner can read", func() {
+				info, err := os.Stat(filepath.Join(configDir, "config.yml"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+			})
+
+			It("lists the targets", func() {
+				session := routingAPICLI("target", "list", "-o", "table")
+
+				Eventually(session).Should(Exit(0))
+				Expect(session.Out).To(Say(`CURRENT\s+NAME\s+API\s+CLIENT_ID\s+OAUTH_URL\n`))
+				Expect(session.Out).To(Say(`\*\s+local\s+` + server.URL() + `\s+some-name\s+` + authServer.URL()))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring("some-secret"))
+			})
 
+			It("uses the current target when no connection flags are given", func() {
 				server.AppendHandlers(
 					ghttp.CombineHandlers(
 						ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+						ghttp.VerifyHeader(http.Header{
+							"Authorization": []string{"bearer " + token},
+						}),
 						ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
 					),
 				)
 
-				session := routingAPICLI(command...)
-
-				expectedRoutes, err := json.Marshal(routes)
-				Expect(err).ToNot(HaveOccurred())
+				session := routingAPICLI("list")
 
 				Eventually(session, "2s").Should(Exit(0))
-				Expect(string(session.Out.Contents())).ToNot(ContainSubstring("uaa-client"))
-				Expect(string(session.Out.Contents())).To(ContainSubstring(string(expectedRoutes) + "\n"))
 				Expect(server.ReceivedRequests()).To(HaveLen(1))
+				Expect(authServer.ReceivedRequests()).To(HaveLen(1))
 			})
 
-			Context("with RTR_TRACE=true", func() {
-				BeforeEach(func() {
-					os.Setenv("RTR_TRACE", "true")
-				})
-				It("Lists the routes and logs uaa messages", func() {
-					routes := []models.Route{
-						models.NewRoute("llama.example.com", 0, "", "yo", "", 5),
-						models.NewRoute("example.com", 8, "11", "yo", "", 1),
-					}
-					command := buildCommand("list", flags, []string{})
+			It("prefers the flags over the current target", func() {
+				otherServer := ghttp.NewServer()
+				defer otherServer.Close()
+				otherServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
+					),
+				)
 
-					server.AppendHandlers(
-						ghttp.CombineHandlers(
-							ghttp.VerifyRequest("GET", "/routing/v1/routes"),
-							ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
-						),
-					)
+				otherFlags := append([]string{}, flags...)
+				otherFlags[1] = otherServer.URL()
+				session := routingAPICLI(buildCommand("list", otherFlags, []string{})...)
 
-					session := routingAPICLI(command...)
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(otherServer.ReceivedRequests()).To(HaveLen(1))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+			})
 
-					expectedRoutes, err := json.Marshal(routes)
-					Expect(err).ToNot(HaveOccurred())
+			It("does not combine the flags with the credentials of the current target", func() {
+				otherServer := ghttp.NewServer()
+				defer otherServer.Close()
+
+				session := routingAPICLI("list", "--api", otherServer.URL())
+
+				Eventually(session).Should(Exit(1))
+				Expect(session.Out).To(Say("Must provide the id of an OAuth client."))
+				Expect(otherServer.ReceivedRequests()).To(BeEmpty())
+				Expect(authServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("switches and removes targets", func() {
+				session := routingAPICLI(buildCommand("target", append([]string{"add"}, flags...), []string{"other"})...)
+				Eventually(session).Should(Exit(0))
+
+				session = routingAPICLI("target", "use", "other")
+				Eventually(session).Should(Exit(0))
+				Expect(session.Out).To(Say("Current target: other"))
+
+				session = routingAPICLI("target", "remove", "other")
+				Eventually(session).Should(Exit(0))
+				Expect(session.Out).To(Say("Successfully removed target: other"))
+
+				session = routingAPICLI("list")
+				Eventually(session).Should(Exit(1))
+				Expect(session.Out).To(Say("Must provide an API endpoint for the routing-api component."))
+			})
+
+			It("fails for unknown targets", func() {
+				session := routingAPICLI("list", "--target", "unknown")
+
+				Eventually(session).Should(Exit(1))
+				Expect(session.Out).To(Say("Unknown target: unknown"))
+			})
+		})
+
+		Describe("login and logout", func() {
+			var routes []models.Route
+
+			BeforeEach(func() {
+				routes = []models.Route{
+					models.NewRoute("llama.example.com", 0, "", "yo", "", 5),
+				}
+				server.RouteToHandler("GET", "/routing/v1/routes", ghttp.CombineHandlers(
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": []string{"bearer " + token},
+					}),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
+				))
+				authServer.RouteToHandler("POST", "/oauth/token",
+					func(w http.ResponseWriter, req *http.Request) {
+						w.Header().Set("Content-Type", "application/json")
+						w.Write([]byte(`{"access_token":"some-token", "expires_in":3600}`))
+					})
+			})
+
+			It("reuses the cached token until logging out", func() {
+				session := routingAPICLI(buildCommand("login", flags, []string{})...)
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say("Successfully logged in as some-name"))
+				Expect(authServer.ReceivedRequests()).To(HaveLen(1))
+
+				info, err := os.Stat(filepath.Join(configDir, "tokens.yml"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
 
+				for i := 0; i < 2; i++ {
+					session = routingAPICLI(buildCommand("list", flags, []string{})...)
 					Eventually(session, "2s").Should(Exit(0))
-					Expect(string(session.Out.Contents())).To(ContainSubstring(string(expectedRoutes) + "\n"))
-					Expect(server.ReceivedRequests()).To(HaveLen(1))
-				})
+				}
+				Expect(server.ReceivedRequests()).To(HaveLen(2))
+				Expect(authServer.ReceivedRequests()).To(HaveLen(1))
+
+				session = routingAPICLI(buildCommand("logout", flags, []string{})...)
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(session.Out).To(Say("Successfully logged out some-name"))
+
+				session = routingAPICLI(buildCommand("list", flags, []string{})...)
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(authServer.ReceivedRequests()).To(HaveLen(2))
+			})
+
+			It("fetches a new token once the cached one is about to expire", func() {
+				authServer.RouteToHandler("POST", "/oauth/token",
+					func(w http.ResponseWriter, req *http.Request) {
+						w.Header().Set("Content-Type", "application/json")
+						w.Write([]byte(`{"access_token":"some-token", "expires_in":10}`))
+					})
+
+				session := routingAPICLI(buildCommand("login", flags, []string{})...)
+				Eventually(session, "2s").Should(Exit(0))
+
+				session = routingAPICLI(buildCommand("list", flags, []string{})...)
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(authServer.ReceivedRequests()).To(HaveLen(2))
+			})
+
+			It("does not cache tokens without logging in", func() {
+				for i := 0; i < 2; i++ {
+					session := routingAPICLI(buildCommand("list", flags, []string{})...)
+					Eventually(session, "2s").Should(Exit(0))
+				}
+				Expect(authServer.ReceivedRequests()).To(HaveLen(2))
+			})
+
+			It("removes all cached tokens", func() {
+				session := routingAPICLI(buildCommand("login", flags, []string{})...)
+				Eventually(session, "2s").Should(Exit(0))
+
+				session = routingAPICLI("logout", "--all")
+				Eventually(session, "2s").Should(Exit(0))
+				Expect(filepath.Join(configDir, "tokens.yml")).NotTo(BeAnExistingFile())
 			})
 		})
 
@@ -351,6 +1279,19 @@ var _ = Describe("Main", func() {
 				Expect(string(session.Out.Contents())).To(ContainSubstring("Connection closed: "))
 			})
 
+			It("logs reconnect attempts to stderr", func() {
+				command := buildCommand("events", flags, []string{"--http", "--forever", "--retry-backoff", "10ms"})
+
+				server.RouteToHandler("GET", "/routing/v1/events", ghttp.RespondWith(http.StatusOK, ""))
+
+				session := routingAPICLI(command...)
+				defer session.Kill()
+
+				Eventually(session.Err, "10s").Should(Say("HTTP event stream failed: .*, reconnecting in 10ms \\(attempt 1\\)"))
+				Eventually(session.Err, "10s").Should(Say("reconnecting in 20ms \\(attempt 2\\)"))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring("reconnecting"))
+			})
+
 			Context("when --http flag is provided", func() {
 				var flagsWithHttp []string
 
@@ -372,6 +1313,46 @@ var _ = Describe("Main", func() {
 				})
 			})
 
+			Context("with filters", func() {
+				BeforeEach(func() {
+					server.RouteToHandler("GET", "/routing/v1/events", sseEventHandler)
+					server.RouteToHandler("GET", "/routing/v1/tcp_routes/events", sseEventTcpHandler)
+				})
+
+				It("only prints the matching events", func() {
+					command := buildCommand("events", flags, []string{"--route", "*.a.k", "--ip", "42.42.0.0/16"})
+
+					session := routingAPICLI(command...)
+
+					Eventually(session, "2s").Should(Exit(0))
+					Expect(string(session.Out.Contents())).To(ContainSubstring(string(httpEventString)))
+					Expect(string(session.Out.Contents())).NotTo(ContainSubstring(string(tcpEventString)))
+				})
+
+				It("matches routes with regular expressions", func() {
+					command := buildCommand("events", flags, []string{"--route", `/^z\.A\./`})
+
+					session := routingAPICLI(command...)
+
+					Eventually(session, "2s").Should(Exit(0))
+					Expect(string(session.Out.Contents())).To(ContainSubstring(string(httpEventString)))
+					Expect(string(session.Out.Contents())).NotTo(ContainSubstring(string(tcpEventString)))
+				})
+
+				It("resolves router group names", func() {
+					server.RouteToHandler("GET", "/routing/v1/router_groups", ghttp.RespondWithJSONEncoded(http.StatusOK, []models.RouterGroup{
+						{Guid: "some-guid", Name: "default-tcp", Type: "tcp"},
+					}))
+					command := buildCommand("events", flags, []string{"--router-group", "default-tcp"})
+
+					session := routingAPICLI(command...)
+
+					Eventually(session, "2s").Should(Exit(0))
+					Expect(string(session.Out.Contents())).To(ContainSubstring(string(tcpEventString)))
+					Expect(string(session.Out.Contents())).NotTo(ContainSubstring(string(httpEventString)))
+				})
+			})
+
 			Context("when --tcp flag is provided", func() {
 				var flagsWithTcp []string
 
@@ -486,7 +1467,7 @@ var _ = Describe("Main", func() {
 			})
 
 			It("successfully requests a token", func() {
-				command := buildCommand("register", flags, []string{"[{}]"})
+				command := buildCommand("register", flags, []string{`[{"route":"zak.com","port":8080,"ip":"1.2.3.4","ttl":60}]`})
 				session := routingAPICLI(command...)
 
 				Eventually(session, "2s").Should(Exit(0))
@@ -494,7 +1475,7 @@ var _ = Describe("Main", func() {
 			})
 
 			It("successfully connects to routing api", func() {
-				command := buildCommand("register", flags, []string{"[{}]"})
+				command := buildCommand("register", flags, []string{`[{"route":"zak.com","port":8080,"ip":"1.2.3.4","ttl":60}]`})
 				session := routingAPICLI(command...)
 
 				Eventually(session, "2s").Should(Exit(0))
@@ -513,6 +1494,61 @@ var _ = Describe("Main", func() {
 		})
 
 		Context("environment variables", func() {
+			Context("connection settings", func() {
+				BeforeEach(func() {
+					routes := []models.Route{
+						models.NewRoute("llama.example.com", 0, "", "yo", "", 5),
+					}
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/routing/v1/routes"),
+							ghttp.VerifyHeader(http.Header{
+								"Authorization": []string{"bearer " + token},
+							}),
+							ghttp.RespondWithJSONEncoded(http.StatusOK, routes),
+						),
+					)
+
+					os.Setenv("RTR_API", server.URL())
+					os.Setenv("RTR_CLIENT_ID", "some-name")
+					os.Setenv("RTR_CLIENT_SECRET", "some-secret")
+					os.Setenv("RTR_OAUTH_URL", authServer.URL())
+					os.Setenv("RTR_CA_CERTS", caLocation)
+				})
+
+				AfterEach(func() {
+					for _, name := range []string{"RTR_API", "RTR_CLIENT_ID", "RTR_CLIENT_SECRET", "RTR_OAUTH_URL", "RTR_CA_CERTS"} {
+						os.Unsetenv(name)
+					}
+				})
+
+				It("falls back to the environment variables when no flags are given", func() {
+					session := routingAPICLI("list")
+
+					Eventually(session, "2s").Should(Exit(0))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+					Expect(authServer.ReceivedRequests()).To(HaveLen(1))
+				})
+
+				It("reads the client secret from --client-secret-file", func() {
+					os.Unsetenv("RTR_CLIENT_SECRET")
+					secretFile := filepath.Join(configDir, "secret")
+					Expect(os.WriteFile(secretFile, []byte("some-secret\n"), 0600)).To(Succeed())
+
+					session := routingAPICLI("list", "--client-secret-file", secretFile)
+
+					Eventually(session, "2s").Should(Exit(0))
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+				})
+
+				It("fails when the client secret file cannot be read", func() {
+					session := routingAPICLI("list", "--client-secret-file", filepath.Join(configDir, "missing"))
+
+					Eventually(session).Should(Exit(1))
+					Expect(session.Out).To(Say("Unable to read client secret file"))
+				})
+			})
+
 			Context("RTR_TRACE", func() {
 				var session *Session
 				BeforeEach(func() {
@@ -656,6 +1692,54 @@ var _ = Describe("Main", func() {
 				Eventually(session).Should(Say("Must provide routes JSON."))
 			})
 
+			It("fails if routes are given both as JSON and with --file", func() {
+				command := buildCommand("register", flags, []string{"-f", "routes.json", "[{}]"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Must provide routes either as JSON, with --file or with --route, not several."))
+			})
+
+			It("fails if the route flags are given without --route", func() {
+				command := buildCommand("register", flags, []string{"--backend", "1.2.3.4:8080"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Must provide --route with the other route flags."))
+			})
+
+			It("fails if backends are given both with --backend and --ip", func() {
+				command := buildCommand("register", flags, []string{"--route", "foo.com", "--ip", "1.2.3.4", "--backend", "1.2.3.4:8080"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Must provide backends either with --backend or with --ip and --port, not both."))
+			})
+
+			It("fails if the input format is unknown", func() {
+				command := buildCommand("register", flags, []string{"--input-format", "xml", "[{}]"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Invalid input format: xml: must be one of: json, yaml, csv"))
+			})
+
+			It("fails if routes are given as JSON with --stream", func() {
+				command := buildCommand("register", flags, []string{"--stream", "[{}]"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("--stream reads routes from stdin, must not provide routes JSON, --file or --route."))
+			})
+
+			It("fails if the routes file cannot be read", func() {
+				command := buildCommand("register", flags, []string{"-f", "does-not-exist.json"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(3))
+				Eventually(session).Should(Say("route registration failed: stat does-not-exist.json"))
+			})
+
 			It("fails if the request has invalid json", func() {
 				command := buildCommand("register", flags, []string{`[{"kind":"of","valid":"json}]`})
 				session := routingAPICLI(command...)
@@ -672,8 +1756,19 @@ var _ = Describe("Main", func() {
 				Eventually(session).Should(Say("Unexpected arguments."))
 			})
 
+			It("lists every problem of invalid routes before contacting the routing api", func() {
+				command := buildCommand("register", flags, []string{`[{"route":"zak.com","port":8080,"ip":"1.2.3.4","ttl":60},{"route":"jak..com","ip":"11","ttl":60}]`})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(3))
+				Expect(session.Out).To(Say("route registration failed: found 3 problems in the routes:"))
+				Expect(session.Out).To(Say(`route 1 \(jak..com\): invalid hostname: jak..com`))
+				Expect(session.Out).To(Say(`route 1 \(jak..com\): invalid ip: "11"`))
+				Expect(session.Out).To(Say(`route 1 \(jak..com\): port must be between 1 and 65535`))
+			})
+
 			It("shows the error if registration fails", func() {
-				command := buildCommand("register", flags, []string{"[{}]"})
+				command := buildCommand("register", flags, []string{"--no-validate", "[{}]"})
 				session := routingAPICLI(command...)
 
 				Eventually(session, 5*time.Second).Should(Exit(3))
@@ -707,7 +1802,7 @@ var _ = Describe("Main", func() {
 			})
 
 			It("shows the error if unregistration fails", func() {
-				command := buildCommand("unregister", flags, []string{"[{}]"})
+				command := buildCommand("unregister", flags, []string{"--no-validate", "[{}]"})
 				session := routingAPICLI(command...)
 
 				Eventually(session, 5*time.Second).Should(Say("route unregistration failed:"))
@@ -724,6 +1819,38 @@ var _ = Describe("Main", func() {
 				Eventually(session).Should(Say("Unexpected arguments."))
 			})
 
+			It("rejects a negative number of retries", func() {
+				command := buildCommand("events", flags, []string{"--max-retries", "-1"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Max retries must be between 0 and 65535."))
+			})
+
+			It("rejects retrying without a backoff", func() {
+				command := buildCommand("events", flags, []string{"--forever", "--retry-backoff", "0"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Retry backoff must be positive when retrying forever."))
+			})
+
+			It("rejects invalid route patterns", func() {
+				command := buildCommand("events", flags, []string{"--route", "/(/"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Invalid route pattern: /\\(/: "))
+			})
+
+			It("rejects unknown actions", func() {
+				command := buildCommand("events", flags, []string{"--action", "patch"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Invalid action: patch, must be upsert or delete."))
+			})
+
 			It("shows the error if streaming events fails", func() {
 				command := buildCommand("events", flags, []string{})
 				session := routingAPICLI(command...)
@@ -733,6 +1860,26 @@ var _ = Describe("Main", func() {
 			})
 		})
 
+		Context("tcp-routes", func() {
+			It("checks for the presence of the mappings json", func() {
+				command := buildCommand("tcp-routes", append([]string{"register"}, flags...), []string{})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Must provide TCP route mappings JSON."))
+			})
+		})
+
+		Context("router-groups", func() {
+			It("checks for the presence of the router group name", func() {
+				command := buildCommand("router-groups", append([]string{"delete"}, flags...), []string{})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Must provide a router group name."))
+			})
+		})
+
 		Context("list", func() {
 			It("fails if there are unexpected arguments", func() {
 				command := buildCommand("list", flags, []string{"ice cream"})
@@ -742,6 +1889,14 @@ var _ = Describe("Main", func() {
 				Eventually(session).Should(Say("Unexpected arguments."))
 			})
 
+			It("fails if the output format is unknown", func() {
+				command := buildCommand("list", flags, []string{"--output", "xml"})
+				session := routingAPICLI(command...)
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session).Should(Say("Invalid output format: xml"))
+			})
+
 			It("shows the error if listing routes fails", func() {
 				command := buildCommand("list", flags, []string{})
 				session := routingAPICLI(command...)
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"github.com/urfave/cli"
+)
+
+var migrateCommand = cli.Command{
+	Name:  "migrate",
+	Usage: "Copies routes from one target to another",
+	Description: `Shows the routes that would be created or updated on the --to target, and
+copies them with --apply. TCP routes are moved to the router group of the
+same name, as the guids differ between foundations. The copied routes keep
+their TTL, so they expire on the --to target unless something registers them
+there in time, use --ttl-override to give them a longer one.`,
+	Action: migrateRoutes,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "from",
+			Usage: "Name of the target to copy the routes from (required)",
+		},
+		cli.StringFlag{
+			Name:  "to",
+			Usage: "Name of the target to copy the routes to (required)",
+		},
+		cli.StringSliceFlag{
+			Name:  "route",
+			Usage: "Copy the HTTP routes and TCP routes with an SNI hostname matching this glob, can be repeated (optional)",
+		},
+		cli.StringSliceFlag{
+			Name:  "log-guid",
+			Usage: "Copy the HTTP routes with this log guid, can be repeated (optional)",
+		},
+		cli.StringSliceFlag{
+			Name:  "router-group",
+			Usage: "Copy the TCP routes of this router group name or guid, can be repeated (optional)",
+		},
+		cli.StringSliceFlag{
+			Name:  "rewrite-ip",
+			Usage: "Move backend IPs from one network to another, e.g. 10.0.0.0/16=10.1.0.0/16, can be repeated (optional)",
+		},
+		cli.IntFlag{
+			Name:  "ttl-override",
+			Usage: "Copy the routes with this TTL in seconds instead of their current one (optional)",
+		},
+		cli.BoolFlag{
+			Name:  "apply",
+			Usage: "Copy the routes instead of only showing the changes (optional)",
+		},
+		cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Do not color the changes (optional)",
+		},
+	},
+}
+
+func migrateRoutes(c *cli.Context) {
+	errorMessage := "migrating routes failed:"
+	issues := checkArguments(c, "migrate")
+	issues = append(issues, checkMigrateFlags(c)...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "migrate")
+	}
+
+	migration := commands.Migration{TTLOverride: c.Int("ttl-override")}
+	for _, value := range c.StringSlice("rewrite-ip") {
+		rule, _ := commands.ParseRewriteRule(value)
+		migration.Rewrites = append(migration.Rewrites, rule)
+	}
+
+	fromConn, err := targetConnection(c.String("from"))
+	checkError(errorMessage, err)
+	from, err := newClient(fromConn)
+	checkError(errorMessage, err)
+
+	toConn, err := targetConnection(c.String("to"))
+	checkError(errorMessage, err)
+	to, err := newClient(toConn)
+	checkError(errorMessage, err)
+
+	migration.Scope = commands.Scope{LogGuids: c.StringSlice("log-guid")}
+	for _, route := range c.StringSlice("route") {
+		migration.Scope.Routes = append(migration.Scope.Routes, commands.Glob(route))
+	}
+	migration.Scope.RouterGroupGuids, err = routerGroupGuids(from, c.StringSlice("router-group"))
+	checkError(errorMessage, err)
+
+	plan, err := migration.Plan(from, to)
+	checkError(errorMessage, err)
+
+	printPlan(c, plan)
+
+	if !c.Bool("apply") {
+		fmt.Println("Dry run: run again with --apply to copy the routes")
+		return
+	}
+
+	err = commands.Apply(to, plan)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully copied the routes from %s to %s\n", c.String("from"), c.String("to"))
+}
+
+func checkMigrateFlags(c *cli.Context) []string {
+	var issues []string
+
+	for _, name := range []string{"from", "to"} {
+		if c.String(name) == "" {
+			issues = append(issues, fmt.Sprintf("Must provide a target with --%s.", name))
+			continue
+		}
+
+		conn, err := targetConnection(c.String(name))
+		if err != nil {
+			issues = append(issues, err.Error())
+			continue
+		}
+		issues = append(issues, checkConnection(conn)...)
+	}
+
+	if c.String("from") != "" && c.String("from") == c.String("to") {
+		issues = append(issues, "Must provide different targets with --from and --to.")
+	}
+
+	for _, value := range c.StringSlice("rewrite-ip") {
+		_, err := commands.ParseRewriteRule(value)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("Invalid IP rewrite rule: %s", err))
+		}
+	}
+
+	if c.Int("ttl-override") < 0 {
+		issues = append(issues, "TTL override must not be negative.")
+	}
+
+	return issues
+}
@@ -0,0 +1,477 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a kubectl style JSONPath template, e.g.
+// '{range [*]}{.route}{"\t"}{.ip}{"\n"}{end}'. It is evaluated against the
+// JSON representation of the records, so field names are the JSON ones.
+type jsonPath struct {
+	nodes []jsonPathNode
+}
+
+type jsonPathNode interface{}
+
+type jsonPathText string
+
+type jsonPathExpr []jsonPathStep
+
+type jsonPathRange struct {
+	path jsonPathExpr
+	body []jsonPathNode
+}
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepWildcard
+	stepIndex
+	stepSlice
+	stepRecursive
+	stepFilter
+)
+
+type jsonPathStep struct {
+	kind       stepKind
+	name       string
+	index      int
+	start, end *int
+	filter     *jsonPathFilter
+}
+
+type jsonPathFilter struct {
+	path     jsonPathExpr
+	operator string
+	value    interface{}
+}
+
+var filterOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func parseJSONPath(template string) (*jsonPath, error) {
+	root := &jsonPathRange{}
+	stack := []*jsonPathRange{root}
+
+	for len(template) > 0 {
+		open := strings.IndexByte(template, '{')
+		if open < 0 {
+			stack[len(stack)-1].body = append(stack[len(stack)-1].body, jsonPathText(template))
+			break
+		}
+		if open > 0 {
+			stack[len(stack)-1].body = append(stack[len(stack)-1].body, jsonPathText(template[:open]))
+		}
+
+		end := closingIndex(template, open, '{', '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed action in jsonpath %q", template)
+		}
+		action := strings.TrimSpace(template[open+1 : end])
+		template = template[end+1:]
+
+		current := stack[len(stack)-1]
+		switch {
+		case action == "end":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("jsonpath has an {end} without a {range}")
+			}
+			stack = stack[:len(stack)-1]
+		case strings.HasPrefix(action, "range "):
+			path, err := parseJSONPathExpr(strings.TrimPrefix(action, "range "))
+			if err != nil {
+				return nil, err
+			}
+			r := &jsonPathRange{path: path}
+			current.body = append(current.body, r)
+			stack = append(stack, r)
+		case strings.HasPrefix(action, `"`) || strings.HasPrefix(action, "'"):
+			text, err := unquote(action)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s in jsonpath: %s", action, err)
+			}
+			current.body = append(current.body, jsonPathText(text))
+		default:
+			path, err := parseJSONPathExpr(action)
+			if err != nil {
+				return nil, err
+			}
+			current.body = append(current.body, path)
+		}
+	}
+
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("jsonpath has a {range} without an {end}")
+	}
+	return &jsonPath{nodes: root.body}, nil
+}
+
+func parseJSONPathExpr(expr string) (jsonPathExpr, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "$") || strings.HasPrefix(expr, "@") {
+		expr = expr[1:]
+	}
+
+	var steps jsonPathExpr
+	for len(expr) > 0 {
+		switch {
+		case strings.HasPrefix(expr, ".."):
+			steps = append(steps, jsonPathStep{kind: stepRecursive})
+			expr = expr[1:]
+		case expr[0] == '.':
+			expr = expr[1:]
+			if len(expr) == 0 || expr[0] == '[' {
+				continue
+			}
+			if expr[0] == '*' {
+				steps = append(steps, jsonPathStep{kind: stepWildcard})
+				expr = expr[1:]
+				continue
+			}
+			end := strings.IndexAny(expr, ".[")
+			if end < 0 {
+				end = len(expr)
+			}
+			steps = append(steps, jsonPathStep{kind: stepField, name: expr[:end]})
+			expr = expr[end:]
+		case expr[0] == '[':
+			end := closingIndex(expr, 0, '[', ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in jsonpath expression %q", expr)
+			}
+			step, err := parseSubscript(strings.TrimSpace(expr[1:end]))
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+			expr = expr[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in jsonpath expression, fields start with a '.'", expr)
+		}
+	}
+	return steps, nil
+}
+
+func parseSubscript(subscript string) (jsonPathStep, error) {
+	switch {
+	case subscript == "*":
+		return jsonPathStep{kind: stepWildcard}, nil
+	case strings.HasPrefix(subscript, "?(") && strings.HasSuffix(subscript, ")"):
+		filter, err := parseFilter(subscript[2 : len(subscript)-1])
+		return jsonPathStep{kind: stepFilter, filter: filter}, err
+	case strings.HasPrefix(subscript, "'") || strings.HasPrefix(subscript, `"`):
+		name, err := unquote(subscript)
+		return jsonPathStep{kind: stepField, name: name}, err
+	case strings.Contains(subscript, ":"):
+		bounds := strings.SplitN(subscript, ":", 2)
+		step := jsonPathStep{kind: stepSlice}
+		for i, bound := range bounds {
+			bound = strings.TrimSpace(bound)
+			if bound == "" {
+				continue
+			}
+			n, err := strconv.Atoi(bound)
+			if err != nil {
+				return step, fmt.Errorf("invalid slice [%s] in jsonpath", subscript)
+			}
+			if i == 0 {
+				step.start = &n
+			} else {
+				step.end = &n
+			}
+		}
+		return step, nil
+	}
+
+	n, err := strconv.Atoi(subscript)
+	if err != nil {
+		return jsonPathStep{}, fmt.Errorf("invalid subscript [%s] in jsonpath", subscript)
+	}
+	return jsonPathStep{kind: stepIndex, index: n}, nil
+}
+
+func parseFilter(filter string) (*jsonPathFilter, error) {
+	for _, operator := range filterOperators {
+		i := indexOutsideQuotes(filter, operator)
+		if i < 0 {
+			continue
+		}
+
+		path, err := parseJSONPathExpr(filter[:i])
+		if err != nil {
+			return nil, err
+		}
+
+		literal := strings.TrimSpace(filter[i+len(operator):])
+		var value interface{}
+		if strings.HasPrefix(literal, "'") || strings.HasPrefix(literal, `"`) {
+			value, err = unquote(literal)
+		} else {
+			err = json.Unmarshal([]byte(literal), &value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s in jsonpath filter", literal)
+		}
+		return &jsonPathFilter{path: path, operator: operator, value: value}, nil
+	}
+
+	path, err := parseJSONPathExpr(filter)
+	return &jsonPathFilter{path: path}, err
+}
+
+func (j *jsonPath) execute(w io.Writer, data interface{}) error {
+	return executeNodes(w, j.nodes, data)
+}
+
+func executeNodes(w io.Writer, nodes []jsonPathNode, data interface{}) error {
+	for _, node := range nodes {
+		var err error
+		switch n := node.(type) {
+		case jsonPathText:
+			_, err = io.WriteString(w, string(n))
+		case jsonPathExpr:
+			values := n.evaluate(data)
+			texts := make([]string, 0, len(values))
+			for _, value := range values {
+				texts = append(texts, formatValue(value))
+			}
+			_, err = io.WriteString(w, strings.Join(texts, " "))
+		case *jsonPathRange:
+			for _, value := range n.path.evaluate(data) {
+				err = executeNodes(w, n.body, value)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (expr jsonPathExpr) evaluate(data interface{}) []interface{} {
+	values := []interface{}{data}
+	for _, step := range expr {
+		var next []interface{}
+		for _, value := range values {
+			next = append(next, step.apply(value)...)
+		}
+		values = next
+	}
+	return values
+}
+
+func (step jsonPathStep) apply(value interface{}) []interface{} {
+	switch step.kind {
+	case stepField:
+		if object, ok := value.(map[string]interface{}); ok {
+			if field, ok := object[step.name]; ok {
+				return []interface{}{field}
+			}
+		}
+	case stepWildcard:
+		return children(value)
+	case stepIndex:
+		if list, ok := value.([]interface{}); ok {
+			i := step.index
+			if i < 0 {
+				i += len(list)
+			}
+			if i >= 0 && i < len(list) {
+				return []interface{}{list[i]}
+			}
+		}
+	case stepSlice:
+		if list, ok := value.([]interface{}); ok {
+			start, end := 0, len(list)
+			if step.start != nil {
+				start = clamp(*step.start, len(list))
+			}
+			if step.end != nil {
+				end = clamp(*step.end, len(list))
+			}
+			if start < end {
+				return list[start:end]
+			}
+		}
+	case stepRecursive:
+		return descendants(value)
+	case stepFilter:
+		var matches []interface{}
+		for _, child := range children(value) {
+			if step.filter.matches(child) {
+				matches = append(matches, child)
+			}
+		}
+		return matches
+	}
+	return nil
+}
+
+func (f *jsonPathFilter) matches(value interface{}) bool {
+	results := f.path.evaluate(value)
+	if f.operator == "" {
+		return len(results) > 0
+	}
+
+	for _, result := range results {
+		if compare(result, f.operator, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(left interface{}, operator string, right interface{}) bool {
+	leftNumber, leftIsNumber := left.(float64)
+	rightNumber, rightIsNumber := right.(float64)
+	if leftIsNumber && rightIsNumber {
+		switch operator {
+		case "==":
+			return leftNumber == rightNumber
+		case "!=":
+			return leftNumber != rightNumber
+		case "<":
+			return leftNumber < rightNumber
+		case "<=":
+			return leftNumber <= rightNumber
+		case ">":
+			return leftNumber > rightNumber
+		case ">=":
+			return leftNumber >= rightNumber
+		}
+	}
+
+	leftText, rightText := formatValue(left), formatValue(right)
+	switch operator {
+	case "==":
+		return leftText == rightText
+	case "!=":
+		return leftText != rightText
+	case "<":
+		return leftText < rightText
+	case "<=":
+		return leftText <= rightText
+	case ">":
+		return leftText > rightText
+	case ">=":
+		return leftText >= rightText
+	}
+	return false
+}
+
+func children(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		values := make([]interface{}, 0, len(v))
+		for _, key := range keys {
+			values = append(values, v[key])
+		}
+		return values
+	}
+	return nil
+}
+
+func descendants(value interface{}) []interface{} {
+	values := []interface{}{value}
+	for _, child := range children(value) {
+		values = append(values, descendants(child)...)
+	}
+	return values
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func clamp(i, length int) int {
+	if i < 0 {
+		i += length
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > length {
+		return length
+	}
+	return i
+}
+
+// closingIndex returns the index of the delimiter closing the one at start,
+// skipping nested pairs and quoted strings.
+func closingIndex(s string, start int, open, close byte) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == open:
+			depth++
+		case s[i] == close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func indexOutsideQuotes(s, substr string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case strings.HasPrefix(s[i:], substr):
+			return i
+		}
+	}
+	return -1
+}
+
+func unquote(s string) (string, error) {
+	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") && len(s) >= 2 {
+		return s[1 : len(s)-1], nil
+	}
+	return strconv.Unquote(s)
+}
@@ -0,0 +1,79 @@
+package output_test
+
+import (
+	"bytes"
+
+	"code.cloudfoundry.org/routing-api-cli/output"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Templates", func() {
+	var (
+		buffer *bytes.Buffer
+		routes output.Routes
+	)
+
+	BeforeEach(func() {
+		buffer = new(bytes.Buffer)
+		routes = output.Routes{
+			models.NewRoute("a.example.com", 8080, "10.0.0.1", "guid-a", "https://rs.example.com", 120),
+			models.NewRoute("b.example.com", 80, "10.0.0.2", "guid-b", "", 5),
+			models.NewRoute("c.example.com", 443, "10.0.0.3", "guid-a", "", 60),
+		}
+	})
+
+	Describe("go-template", func() {
+		It("evaluates the template against the json field names", func() {
+			Expect(output.Write(buffer, `go-template={{range .}}{{.route}}:{{.port}}{{"\n"}}{{end}}`, routes)).To(Succeed())
+			Expect(buffer.String()).To(Equal("a.example.com:8080\nb.example.com:80\nc.example.com:443\n"))
+		})
+
+		It("rejects templates that do not parse", func() {
+			Expect(output.ValidateFormat("go-template={{range .}")).To(MatchError(ContainSubstring("invalid go-template")))
+		})
+	})
+
+	Describe("jsonpath", func() {
+		DescribeTable("evaluating expressions",
+			func(template string, expected string) {
+				Expect(output.Write(buffer, "jsonpath="+template, routes)).To(Succeed())
+				Expect(buffer.String()).To(Equal(expected))
+			},
+			Entry("a field of every element", "{[*].route}", "a.example.com b.example.com c.example.com"),
+			Entry("a leading dot", "{.[*].ip}", "10.0.0.1 10.0.0.2 10.0.0.3"),
+			Entry("an index", "{[1].route}", "b.example.com"),
+			Entry("a negative index", "{[-1].route}", "c.example.com"),
+			Entry("a slice", "{[0:2].port}", "8080 80"),
+			Entry("a quoted field", "{[0]['log_guid']}", "guid-a"),
+			Entry("a nested field", "{[0].modification_tag.index}", "0"),
+			Entry("a missing field", "{[*].route_service_url}", "https://rs.example.com"),
+			Entry("recursive descent", "{..log_guid}", "guid-a guid-b guid-a"),
+			Entry("a string filter", `{[?(@.log_guid=="guid-a")].route}`, "a.example.com c.example.com"),
+			Entry("a numeric filter", "{[?(@.port>100)].route}", "a.example.com c.example.com"),
+			Entry("an existence filter", "{[?(@.route_service_url)].route}", "a.example.com"),
+			Entry("text and literals", `routes: {[0].route}{"\n"}`, "routes: a.example.com\n"),
+			Entry("a range", `{range [*]}{.route}{"\t"}{.ttl}{"\n"}{end}`, "a.example.com\t120\nb.example.com\t5\nc.example.com\t60\n"),
+		)
+
+		It("evaluates tcp route mappings", func() {
+			mappings := output.TcpRouteMappings{
+				models.NewTcpRouteMapping("rg-guid", 5200, "10.0.0.3", 60000, 0, "", nil, 60, models.ModificationTag{}),
+			}
+			Expect(output.Write(buffer, "jsonpath={[*].backend_ip}:{[*].backend_port}", mappings)).To(Succeed())
+			Expect(buffer.String()).To(Equal("10.0.0.3:60000"))
+		})
+
+		DescribeTable("invalid templates",
+			func(template string, message string) {
+				Expect(output.ValidateFormat("jsonpath=" + template)).To(MatchError(ContainSubstring(message)))
+			},
+			Entry("unclosed action", "{[*].route", "unclosed action"),
+			Entry("unclosed range", "{range [*]}{.route}", "without an {end}"),
+			Entry("stray end", "{end}", "without a {range}"),
+			Entry("field without a dot", "{route}", "fields start with a '.'"),
+			Entry("invalid subscript", "{[abc]}", "invalid subscript"),
+		)
+	})
+})
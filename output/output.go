@@ -0,0 +1,210 @@
+// Package output renders routing-api records in the formats that can be
+// selected with --output, so that every command prints them the same way.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Table      = "table"
+	JSON       = "json"
+	JSONPretty = "json-pretty"
+	YAML       = "yaml"
+	NDJSON     = "ndjson"
+	CSV        = "csv"
+
+	GoTemplatePrefix = "go-template="
+	JSONPathPrefix   = "jsonpath="
+)
+
+var Formats = []string{Table, JSON, JSONPretty, YAML, NDJSON, CSV, GoTemplatePrefix + "...", JSONPathPrefix + "..."}
+
+// Tabular is implemented by the record lists that can be rendered as a table
+// or as CSV. Header returns the column names, Rows one row per record.
+type Tabular interface {
+	Header() []string
+	Rows() [][]string
+}
+
+// ValidateFormat checks that format is known and, for go-template= and
+// jsonpath=, that its template parses.
+func ValidateFormat(format string) error {
+	switch {
+	case strings.HasPrefix(format, GoTemplatePrefix):
+		_, err := parseGoTemplate(strings.TrimPrefix(format, GoTemplatePrefix))
+		return err
+	case strings.HasPrefix(format, JSONPathPrefix):
+		_, err := parseJSONPath(strings.TrimPrefix(format, JSONPathPrefix))
+		return err
+	}
+
+	for _, f := range Formats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of: %s", strings.Join(Formats, ", "))
+}
+
+// Write renders records to w in the given format.
+func Write(w io.Writer, format string, records Tabular) error {
+	switch {
+	case strings.HasPrefix(format, GoTemplatePrefix):
+		return writeGoTemplate(w, strings.TrimPrefix(format, GoTemplatePrefix), records)
+	case strings.HasPrefix(format, JSONPathPrefix):
+		return writeJSONPath(w, strings.TrimPrefix(format, JSONPathPrefix), records)
+	}
+
+	switch format {
+	case Table:
+		return writeTable(w, records)
+	case JSON:
+		return writeJSON(w, records, "")
+	case JSONPretty:
+		return writeJSON(w, records, "  ")
+	case YAML:
+		return writeYAML(w, records)
+	case NDJSON:
+		return writeNDJSON(w, records)
+	case CSV:
+		return writeCSV(w, records)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+func writeTable(w io.Writer, records Tabular) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	header := records.Header()
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+
+	for _, row := range records.Rows() {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, records Tabular, indent string) error {
+	var data []byte
+	var err error
+	if indent == "" {
+		data, err = json.Marshal(records)
+	} else {
+		data, err = json.MarshalIndent(records, "", indent)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// writeYAML goes through JSON first so that the YAML keys match the JSON
+// field names of the routing-api models.
+func writeYAML(w io.Writer, records Tabular) error {
+	generic, err := toGeneric(records)
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err = encoder.Encode(generic)
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// writeNDJSON writes one line per record, or a single line for records that
+// are one object rather than a list, such as stats.
+func writeNDJSON(w io.Writer, records Tabular) error {
+	encoder := json.NewEncoder(w)
+	list := reflect.ValueOf(records)
+	if list.Kind() != reflect.Slice {
+		return encoder.Encode(records)
+	}
+	for i := 0; i < list.Len(); i++ {
+		err := encoder.Encode(list.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, records Tabular) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(records.Header())
+	if err != nil {
+		return err
+	}
+	err = cw.WriteAll(records.Rows())
+	if err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func parseGoTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid go-template: %s", err)
+	}
+	return tmpl, nil
+}
+
+// writeGoTemplate, like writeJSONPath, evaluates the template against the
+// JSON representation of the records, so it refers to fields by their JSON
+// names, e.g. '{{range .}}{{.route}}{{"\n"}}{{end}}'.
+func writeGoTemplate(w io.Writer, text string, records Tabular) error {
+	tmpl, err := parseGoTemplate(text)
+	if err != nil {
+		return err
+	}
+
+	generic, err := toGeneric(records)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, generic)
+}
+
+func writeJSONPath(w io.Writer, text string, records Tabular) error {
+	path, err := parseJSONPath(text)
+	if err != nil {
+		return err
+	}
+
+	generic, err := toGeneric(records)
+	if err != nil {
+		return err
+	}
+	return path.execute(w, generic)
+}
+
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	err = json.Unmarshal(data, &generic)
+	return generic, err
+}
@@ -0,0 +1,103 @@
+package output_test
+
+import (
+	"bytes"
+	"strconv"
+
+	"code.cloudfoundry.org/routing-api-cli/output"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// summary is a record that is one object rather than a list.
+type summary struct {
+	Count int `json:"count"`
+}
+
+func (s summary) Header() []string { return []string{"count"} }
+
+func (s summary) Rows() [][]string { return [][]string{{strconv.Itoa(s.Count)}} }
+
+var _ = Describe("Write", func() {
+	var (
+		buffer *bytes.Buffer
+		routes output.Routes
+	)
+
+	BeforeEach(func() {
+		buffer = new(bytes.Buffer)
+		route := models.NewRoute("llama.example.com", 8080, "10.0.0.1", "log-guid", "https://rs.example.com", 120)
+		route.ModificationTag = models.ModificationTag{Guid: "tag-guid", Index: 3}
+		routes = output.Routes{
+			route,
+			models.NewRoute("example.com", 80, "10.0.0.2", "", "", 5),
+		}
+	})
+
+	It("renders an aligned table", func() {
+		Expect(output.Write(buffer, output.Table, routes)).To(Succeed())
+		Expect(buffer.String()).To(Equal(
+			"ROUTE              IP        PORT  TTL  LOG_GUID  ROUTE_SERVICE_URL       MODIFICATION_GUID  MODIFICATION_INDEX\n" +
+				"llama.example.com  10.0.0.1  8080  120  log-guid  https://rs.example.com  tag-guid           3\n" +
+				"example.com        10.0.0.2  80    5                                                         0\n"))
+	})
+
+	It("renders minified json", func() {
+		Expect(output.Write(buffer, output.JSON, routes)).To(Succeed())
+		Expect(buffer.String()).To(HavePrefix(`[{"route":"llama.example.com","port":8080,`))
+		Expect(buffer.String()).To(HaveSuffix("}]\n"))
+	})
+
+	It("renders indented json", func() {
+		Expect(output.Write(buffer, output.JSONPretty, routes)).To(Succeed())
+		Expect(buffer.String()).To(HavePrefix("[\n  {\n    \"route\": \"llama.example.com\",\n"))
+	})
+
+	It("renders yaml with the json field names", func() {
+		Expect(output.Write(buffer, output.YAML, routes)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("- ip: 10.0.0.1\n  log_guid: log-guid\n"))
+		Expect(buffer.String()).To(ContainSubstring("  route_service_url: https://rs.example.com\n"))
+	})
+
+	It("renders one json document per line", func() {
+		Expect(output.Write(buffer, output.NDJSON, routes)).To(Succeed())
+		lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+		Expect(lines).To(HaveLen(2))
+		Expect(string(lines[1])).To(HavePrefix(`{"route":"example.com",`))
+	})
+
+	It("renders a single record as one json document", func() {
+		Expect(output.Write(buffer, output.NDJSON, summary{Count: 2})).To(Succeed())
+		Expect(buffer.String()).To(Equal("{\"count\":2}\n"))
+	})
+
+	It("renders csv with a header", func() {
+		Expect(output.Write(buffer, output.CSV, routes)).To(Succeed())
+		Expect(buffer.String()).To(Equal(
+			"route,ip,port,ttl,log_guid,route_service_url,modification_guid,modification_index\n" +
+				"llama.example.com,10.0.0.1,8080,120,log-guid,https://rs.example.com,tag-guid,3\n" +
+				"example.com,10.0.0.2,80,5,,,,0\n"))
+	})
+
+	It("renders tcp route mappings", func() {
+		mappings := output.TcpRouteMappings{
+			models.NewTcpRouteMapping("rg-guid", 5200, "10.0.0.3", 60000, 0, "", nil, 60, models.ModificationTag{}),
+		}
+		Expect(output.Write(buffer, output.CSV, mappings)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("rg-guid,5200,10.0.0.3,60000,,,60,,0\n"))
+	})
+
+	It("renders router groups", func() {
+		groups := output.RouterGroups{
+			{Guid: "rg-guid", Name: "default-tcp", Type: models.RouterGroup_TCP, ReservablePorts: "1024-1033"},
+		}
+		Expect(output.Write(buffer, output.Table, groups)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("rg-guid  default-tcp  tcp   1024-1033"))
+	})
+
+	It("fails for unknown formats", func() {
+		Expect(output.Write(buffer, "xml", routes)).To(MatchError(`unknown output format "xml"`))
+		Expect(output.ValidateFormat("xml")).To(MatchError(ContainSubstring("must be one of: table, json")))
+	})
+})
@@ -0,0 +1,85 @@
+package output
+
+import (
+	"strconv"
+
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+type Routes []models.Route
+
+func (routes Routes) Header() []string {
+	return []string{"route", "ip", "port", "ttl", "log_guid", "route_service_url", "modification_guid", "modification_index"}
+}
+
+func (routes Routes) Rows() [][]string {
+	rows := make([][]string, 0, len(routes))
+	for _, route := range routes {
+		rows = append(rows, []string{
+			route.Route,
+			route.IP,
+			strconv.Itoa(int(route.Port)),
+			FormatTTL(route.TTL),
+			route.LogGuid,
+			route.RouteServiceUrl,
+			route.ModificationTag.Guid,
+			strconv.FormatUint(uint64(route.ModificationTag.Index), 10),
+		})
+	}
+	return rows
+}
+
+type TcpRouteMappings []models.TcpRouteMapping
+
+func (mappings TcpRouteMappings) Header() []string {
+	return []string{"router_group_guid", "port", "backend_ip", "backend_port", "backend_sni_hostname", "isolation_segment", "ttl", "modification_guid", "modification_index"}
+}
+
+func (mappings TcpRouteMappings) Rows() [][]string {
+	rows := make([][]string, 0, len(mappings))
+	for _, mapping := range mappings {
+		sniHostname := ""
+		if mapping.SniHostname != nil {
+			sniHostname = *mapping.SniHostname
+		}
+		rows = append(rows, []string{
+			mapping.RouterGroupGuid,
+			strconv.Itoa(int(mapping.ExternalPort)),
+			mapping.HostIP,
+			strconv.Itoa(int(mapping.HostPort)),
+			sniHostname,
+			mapping.IsolationSegment,
+			FormatTTL(mapping.TTL),
+			mapping.ModificationTag.Guid,
+			strconv.FormatUint(uint64(mapping.ModificationTag.Index), 10),
+		})
+	}
+	return rows
+}
+
+type RouterGroups []models.RouterGroup
+
+func (groups RouterGroups) Header() []string {
+	return []string{"guid", "name", "type", "reservable_ports"}
+}
+
+func (groups RouterGroups) Rows() [][]string {
+	rows := make([][]string, 0, len(groups))
+	for _, group := range groups {
+		rows = append(rows, []string{
+			group.Guid,
+			group.Name,
+			string(group.Type),
+			string(group.ReservablePorts),
+		})
+	}
+	return rows
+}
+
+// FormatTTL renders an optional TTL, leaving unset TTLs empty.
+func FormatTTL(ttl *int) string {
+	if ttl == nil {
+		return ""
+	}
+	return strconv.Itoa(*ttl)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api-cli/output"
+	"code.cloudfoundry.org/routing-api/models"
+	"github.com/urfave/cli"
+)
+
+var routerGroupsCommand = cli.Command{
+	Name:  "router-groups",
+	Usage: "Manages router groups with the routing-api",
+	Subcommands: []cli.Command{
+		{
+			Name:   "list",
+			Usage:  "Lists the router groups",
+			Action: listRouterGroups,
+			Flags:  append(flags, outputFlag),
+		},
+		{
+			Name:      "get",
+			Usage:     "Shows the router group with the given name",
+			ArgsUsage: "<name>",
+			Action:    getRouterGroup,
+			Flags:     flags,
+		},
+		{
+			Name:  "create",
+			Usage: "Creates a router group",
+			Description: `Router groups must be specified in JSON format, like so:
+'{"name":"default-tcp", "type":"tcp", "reservable_ports":"1024-1033"}'`,
+			Action: createRouterGroup,
+			Flags:  flags,
+		},
+		{
+			Name:  "update",
+			Usage: "Updates the reservable ports of a router group",
+			Description: `Router groups must be specified in JSON format, like so:
+'{"name":"default-tcp", "type":"tcp", "reservable_ports":"1024-1040"}'
+The guid is looked up by name when it is omitted.`,
+			Action: updateRouterGroup,
+			Flags:  flags,
+		},
+		{
+			Name:      "delete",
+			Usage:     "Deletes the router group with the given name",
+			ArgsUsage: "<name>",
+			Action:    deleteRouterGroup,
+			Flags:     flags,
+		},
+	},
+}
+
+func listRouterGroups(c *cli.Context) {
+	errorMessage := "listing router groups failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "list")...)
+	issues = append(issues, checkOutputFormat(c)...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "list")
+	}
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	groups, err := commands.ListRouterGroups(client)
+	checkError(errorMessage, err)
+
+	printRecords(c, errorMessage, output.RouterGroups(groups))
+}
+
+func getRouterGroup(c *cli.Context) {
+	errorMessage := "getting router group failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "get")...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "get")
+	}
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	group, err := commands.GetRouterGroup(client, c.Args().First())
+	checkError(errorMessage, err)
+
+	prettyGroup, _ := json.Marshal(group)
+
+	fmt.Printf("%v\n", string(prettyGroup))
+}
+
+func createRouterGroup(c *cli.Context) {
+	errorMessage := "router group creation failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "create")...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "create")
+	}
+
+	desiredGroup := c.Args().First()
+	var group models.RouterGroup
+	err := json.Unmarshal([]byte(desiredGroup), &group)
+	checkError(errorMessage, err)
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	err = commands.CreateRouterGroup(client, group)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully created router group: %s\n", desiredGroup)
+}
+
+func updateRouterGroup(c *cli.Context) {
+	errorMessage := "router group update failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "update")...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "update")
+	}
+
+	desiredGroup := c.Args().First()
+	var group models.RouterGroup
+	err := json.Unmarshal([]byte(desiredGroup), &group)
+	checkError(errorMessage, err)
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	err = commands.UpdateRouterGroup(client, group)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully updated router group: %s\n", desiredGroup)
+}
+
+func deleteRouterGroup(c *cli.Context) {
+	errorMessage := "router group deletion failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "delete")...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "delete")
+	}
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	name := c.Args().First()
+	err = commands.DeleteRouterGroup(client, name)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully deleted router group: %s\n", name)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"github.com/urfave/cli"
+)
+
+var statsCommand = cli.Command{
+	Name:   "stats",
+	Usage:  "Summarizes the registered routes",
+	Action: showStats,
+	Flags: append(flags,
+		outputFlag,
+		cli.IntFlag{
+			Name:  "top",
+			Value: 10,
+			Usage: "Number of hostnames with the most backends to show (optional)",
+		},
+	),
+}
+
+type routeGroups []commands.RouteGroup
+
+func (groups routeGroups) Header() []string {
+	return []string{"key", "routes", "hosts", "backends"}
+}
+
+func (groups routeGroups) Rows() [][]string {
+	rows := make([][]string, 0, len(groups))
+	for _, group := range groups {
+		rows = append(rows, []string{
+			group.Key,
+			strconv.Itoa(group.Routes),
+			strconv.Itoa(group.Hosts),
+			strconv.Itoa(group.Backends),
+		})
+	}
+	return rows
+}
+
+// statsRecord renders the stats as JSON like other records, and as a table
+// of one figure per row.
+type statsRecord commands.Stats
+
+func (stats statsRecord) Header() []string {
+	return []string{"stat", "value"}
+}
+
+func (stats statsRecord) Rows() [][]string {
+	rows := [][]string{
+		{"routes", strconv.Itoa(stats.Routes)},
+		{"tcp_routes", strconv.Itoa(stats.TcpRoutes)},
+		{"unique_hosts", strconv.Itoa(stats.UniqueHosts)},
+		{"unique_backend_ips", strconv.Itoa(stats.UniqueBackendIPs)},
+		{"route_service_routes", strconv.Itoa(stats.RouteServiceRoutes)},
+	}
+	for _, bucket := range stats.TTLHistogram {
+		rows = append(rows, []string{"ttl " + bucket.TTL, strconv.Itoa(bucket.Routes)})
+	}
+	for _, host := range stats.TopHosts {
+		rows = append(rows, []string{"top_host " + host.Key, fmt.Sprintf("%d backends, %d routes", host.Backends, host.Routes)})
+	}
+	return rows
+}
+
+func showStats(c *cli.Context) {
+	errorMessage := "computing stats failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "stats")...)
+	issues = append(issues, checkOutputFormat(c)...)
+	if c.Int("top") < 0 {
+		issues = append(issues, "Top must not be negative.")
+	}
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "stats")
+	}
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	routes, err := commands.List(client)
+	checkError(errorMessage, err)
+
+	mappings, err := commands.ListTcp(client, nil)
+	checkError(errorMessage, err)
+
+	stats := commands.ComputeStats(routes, mappings, c.Int("top"))
+	printRecords(c, errorMessage, statsRecord(stats))
+}
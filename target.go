@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/routing-api-cli/config"
+	"github.com/urfave/cli"
+)
+
+var targetCommand = cli.Command{
+	Name:  "target",
+	Usage: "Manages the routing-api targets in the config file",
+	Description: `Targets hold the connection flags of a routing-api, so that they do not have to be
+given on every command. Commands use the current target, or the one named with --target,
+for every connection flag that is not given.`,
+	Subcommands: []cli.Command{
+		{
+			Name:      "add",
+			Usage:     "Adds a target, or replaces the target with the same name",
+			ArgsUsage: "<name>",
+			Action:    addTarget,
+			Flags:     connectionFlags,
+		},
+		{
+			Name:      "use",
+			Usage:     "Makes the target with the given name the current one",
+			ArgsUsage: "<name>",
+			Action:    useTarget,
+		},
+		{
+			Name:   "list",
+			Usage:  "Lists the targets",
+			Action: listTargets,
+			Flags:  []cli.Flag{outputFlag},
+		},
+		{
+			Name:      "remove",
+			Usage:     "Removes the target with the given name",
+			ArgsUsage: "<name>",
+			Action:    removeTarget,
+		},
+	},
+}
+
+type targetSummary struct {
+	Current  bool   `json:"current"`
+	Name     string `json:"name"`
+	API      string `json:"api"`
+	ClientID string `json:"client_id"`
+	OAuthURL string `json:"oauth_url"`
+}
+
+type targetSummaries []targetSummary
+
+func (targets targetSummaries) Header() []string {
+	return []string{"current", "name", "api", "client_id", "oauth_url"}
+}
+
+func (targets targetSummaries) Rows() [][]string {
+	rows := make([][]string, 0, len(targets))
+	for _, target := range targets {
+		current := ""
+		if target.Current {
+			current = "*"
+		}
+		rows = append(rows, []string{current, target.Name, target.API, target.ClientID, target.OAuthURL})
+	}
+	return rows
+}
+
+func addTarget(c *cli.Context) {
+	errorMessage := "adding target failed:"
+	var issues []string
+	conn, err := flagConnection(c)
+	if err != nil {
+		issues = append(issues, err.Error())
+	} else {
+		issues = append(issues, checkConnection(conn)...)
+	}
+	issues = append(issues, checkArguments(c, "add")...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "add")
+	}
+
+	path, cfg := loadConfig(errorMessage)
+
+	name := c.Args().First()
+	err = cfg.Add(config.Target{
+		Name:                name,
+		API:                 conn.api,
+		ClientID:            conn.clientID,
+		ClientSecret:        conn.clientSecret,
+		OAuthURL:            conn.oauthURL,
+		CACerts:             conn.caCerts,
+		SkipTLSVerification: conn.skipTLSVerification,
+	})
+	checkError(errorMessage, err)
+
+	err = cfg.Save(path)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully added target: %s\n", name)
+	if cfg.Current == name {
+		fmt.Printf("Current target: %s\n", name)
+	}
+}
+
+func useTarget(c *cli.Context) {
+	errorMessage := "switching target failed:"
+	issues := checkArguments(c, "use")
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "use")
+	}
+
+	path, cfg := loadConfig(errorMessage)
+
+	name := c.Args().First()
+	err := cfg.Use(name)
+	checkError(errorMessage, err)
+
+	err = cfg.Save(path)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Current target: %s\n", name)
+}
+
+func listTargets(c *cli.Context) {
+	errorMessage := "listing targets failed:"
+	issues := checkArguments(c, "list")
+	issues = append(issues, checkOutputFormat(c)...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "list")
+	}
+
+	_, cfg := loadConfig(errorMessage)
+
+	targets := targetSummaries{}
+	for _, target := range cfg.Targets {
+		targets = append(targets, targetSummary{
+			Current:  target.Name == cfg.Current,
+			Name:     target.Name,
+			API:      target.API,
+			ClientID: target.ClientID,
+			OAuthURL: target.OAuthURL,
+		})
+	}
+
+	printRecords(c, errorMessage, targets)
+}
+
+func removeTarget(c *cli.Context) {
+	errorMessage := "removing target failed:"
+	issues := checkArguments(c, "remove")
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "remove")
+	}
+
+	path, cfg := loadConfig(errorMessage)
+
+	name := c.Args().First()
+	err := cfg.Remove(name)
+	checkError(errorMessage, err)
+
+	err = cfg.Save(path)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully removed target: %s\n", name)
+}
+
+func loadConfig(errorMessage string) (string, *config.Config) {
+	path, err := config.Path()
+	checkError(errorMessage, err)
+
+	cfg, err := config.Load(path)
+	checkError(errorMessage, err)
+
+	return path, cfg
+}
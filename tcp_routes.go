@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api-cli/output"
+	"code.cloudfoundry.org/routing-api/models"
+	"github.com/urfave/cli"
+)
+
+var tcpListFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "isolation-segment",
+		Usage: "Only list TCP routes of this isolation segment, can be repeated (optional)",
+	},
+	outputFlag,
+}
+
+var tcpRoutesCommand = cli.Command{
+	Name:  "tcp-routes",
+	Usage: "Manages TCP route mappings with the routing-api",
+	Subcommands: []cli.Command{
+		{
+			Name:   "list",
+			Usage:  "Lists the currently registered TCP route mappings",
+			Action: listTcpRoutes,
+			Flags:  append(flags, tcpListFlags...),
+		},
+		{
+			Name:  "register",
+			Usage: "Registers TCP route mappings with the routing-api",
+			Description: `TCP route mappings must be specified in JSON format, like so:
+'[{"router_group_guid":"guid", "port":5200, "backend_ip":"1.2.3.4", "backend_port":60000, "ttl":60}]'`,
+			Action: registerTcpRoutes,
+			Flags:  flags,
+		},
+		{
+			Name:  "unregister",
+			Usage: "Unregisters TCP route mappings with the routing-api",
+			Description: `TCP route mappings must be specified in JSON format, like so:
+'[{"router_group_guid":"guid", "port":5200, "backend_ip":"1.2.3.4", "backend_port":60000}]'`,
+			Action: unregisterTcpRoutes,
+			Flags:  flags,
+		},
+	},
+}
+
+func listTcpRoutes(c *cli.Context) {
+	errorMessage := "listing tcp routes failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "list")...)
+	issues = append(issues, checkOutputFormat(c)...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "list")
+	}
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	mappings, err := commands.ListTcp(client, c.StringSlice("isolation-segment"))
+	checkError(errorMessage, err)
+
+	printRecords(c, errorMessage, output.TcpRouteMappings(mappings))
+}
+
+func registerTcpRoutes(c *cli.Context) {
+	errorMessage := "tcp route registration failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "tcp-register")...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "register")
+	}
+
+	desiredMappings := c.Args().First()
+	var mappings []models.TcpRouteMapping
+	err := json.Unmarshal([]byte(desiredMappings), &mappings)
+	checkError(errorMessage, err)
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	err = commands.RegisterTcp(client, mappings)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully registered tcp routes: %s\n", desiredMappings)
+}
+
+func unregisterTcpRoutes(c *cli.Context) {
+	errorMessage := "tcp route unregistration failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "tcp-unregister")...)
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "unregister")
+	}
+
+	desiredMappings := c.Args().First()
+	var mappings []models.TcpRouteMapping
+	err := json.Unmarshal([]byte(desiredMappings), &mappings)
+	checkError(errorMessage, err)
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	err = commands.UnregisterTcp(client, mappings)
+	checkError(errorMessage, err)
+
+	fmt.Printf("Successfully unregistered tcp routes: %s\n", desiredMappings)
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"code.cloudfoundry.org/routing-api-cli/output"
+	"code.cloudfoundry.org/routing-api/models"
+	"github.com/urfave/cli"
+)
+
+// watchRefreshInterval is how often the watched routes are rendered again
+// when they changed or rows are highlighted.
+const watchRefreshInterval = 500 * time.Millisecond
+
+var watchCommand = cli.Command{
+	Name:   "watch",
+	Usage:  "Lists the routes and keeps the list up to date with the route events",
+	Action: watchRoutes,
+	Flags: append(append(flags, routeFilterFlags...),
+		cli.BoolFlag{
+			Name:  "http",
+			Usage: "Watch only the HTTP routes (optional)",
+		},
+		cli.BoolFlag{
+			Name:  "tcp",
+			Usage: "Watch only the TCP routes (optional)",
+		},
+		cli.DurationFlag{
+			Name:  "highlight",
+			Value: 5 * time.Second,
+			Usage: "How long added, updated and removed routes stay highlighted (optional)",
+		},
+		cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Do not color the changes (optional)",
+		},
+	),
+}
+
+// watchRows renders the rows of a RouteWatch, with router groups by name.
+type watchRows struct {
+	rows         []commands.WatchRow
+	routerGroups map[string]string
+}
+
+func (rows watchRows) Header() []string {
+	return []string{"", "type", "route", "backend", "ttl", "log_guid"}
+}
+
+func (rows watchRows) Rows() [][]string {
+	records := make([][]string, 0, len(rows.rows))
+	for _, row := range rows.rows {
+		if row.Route != nil {
+			records = append(records, []string{
+				watchMark(row.State),
+				"http",
+				row.Route.Route,
+				row.Route.IP + ":" + strconv.Itoa(int(row.Route.Port)),
+				output.FormatTTL(row.Route.TTL),
+				row.Route.LogGuid,
+			})
+			continue
+		}
+
+		mapping := row.TcpRoute
+		group := mapping.RouterGroupGuid
+		if name, ok := rows.routerGroups[group]; ok {
+			group = name
+		}
+		route := group + ":" + strconv.Itoa(int(mapping.ExternalPort))
+		if mapping.SniHostname != nil && *mapping.SniHostname != "" {
+			route += " sni " + *mapping.SniHostname
+		}
+		records = append(records, []string{
+			watchMark(row.State),
+			"tcp",
+			route,
+			mapping.HostIP + ":" + strconv.Itoa(int(mapping.HostPort)),
+			output.FormatTTL(mapping.TTL),
+			"",
+		})
+	}
+	return records
+}
+
+func watchRoutes(c *cli.Context) {
+	errorMessage := "watching routes failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "watch")...)
+
+	options, filterIssues := listOptions(c)
+	issues = append(issues, filterIssues...)
+
+	if c.Duration("highlight") <= 0 {
+		issues = append(issues, "Highlight must be positive.")
+	}
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "watch")
+	}
+
+	watchHttp := c.Bool("http")
+	watchTcp := c.Bool("tcp")
+
+	if !watchHttp && !watchTcp {
+		watchHttp = true
+		watchTcp = true
+	}
+
+	conn, err := resolveConnection(c)
+	checkError(errorMessage, err)
+
+	token, err := accessToken(conn)
+	checkError(errorMessage, err)
+
+	client := routing_api.NewClient(conn.api, conn.skipTLSVerification)
+	client.SetToken(token.AccessToken)
+
+	watch := &commands.RouteWatch{
+		Filter:    options.Filter,
+		Highlight: c.Duration("highlight"),
+		Clock:     clock.NewClock(),
+	}
+
+	refresher := newTokenRefresher(conn, client, token)
+	stop := make(chan struct{})
+	defer close(stop)
+	go refresher.Run(stop)
+
+	httpEvents := make(chan routing_api.Event)
+	tcpEvents := make(chan routing_api.TcpEvent)
+	errorChan := make(chan error)
+	statusChan := make(chan string)
+	subscribed := make(chan struct{})
+
+	streams := 0
+
+	if watchHttp {
+		streams++
+		stream := newWatchStream(client, refresher, "HTTP", statusChan, subscribed)
+		go func() {
+			errorChan <- stream.StreamHttp(func(e routing_api.Event) { httpEvents <- e })
+		}()
+	}
+
+	if watchTcp {
+		streams++
+		stream := newWatchStream(client, refresher, "TCP", statusChan, subscribed)
+		go func() {
+			errorChan <- stream.StreamTcp(func(e routing_api.TcpEvent) { tcpEvents <- e })
+		}()
+	}
+
+	// The routes are loaded once every stream subscribed, so that no change
+	// made in between is missed, and again whenever a subscription was
+	// renewed, since events are missed while none is open. Events received
+	// before the first snapshot are buffered and replayed on top of it.
+	var snapshot watchSnapshot
+	var buffered []func()
+	status := ""
+	loaded, reloading := false, false
+	load := func() {
+		var reloaded watchSnapshot
+		err := refresher.WithClient(func() error {
+			var err error
+			reloaded, err = loadWatchSnapshot(client, watchHttp, watchTcp)
+			return err
+		})
+		if err != nil {
+			status, reloading = fmt.Sprintf("Loading the routes failed: %s, retrying", err), true
+			return
+		}
+
+		snapshot, status, reloading = reloaded, "", false
+		watch.Load(snapshot.routes, snapshot.mappings)
+		for _, apply := range buffered {
+			apply()
+		}
+		buffered, loaded = nil, true
+	}
+
+	terminal := isTerminal(os.Stdout)
+	color := terminal && !c.Bool("no-color")
+	ticker := time.NewTicker(watchRefreshInterval)
+	defer ticker.Stop()
+
+	dirty, highlighted := false, false
+	for {
+		select {
+		case e := <-httpEvents:
+			if !loaded {
+				buffered = append(buffered, func() { watch.ApplyHttp(e) })
+				continue
+			}
+			dirty = watch.ApplyHttp(e) || dirty
+		case e := <-tcpEvents:
+			if !loaded {
+				buffered = append(buffered, func() { watch.ApplyTcp(e) })
+				continue
+			}
+			dirty = watch.ApplyTcp(e) || dirty
+		case status = <-statusChan:
+			dirty = true
+		case <-subscribed:
+			if streams > 0 {
+				streams--
+			}
+			if streams == 0 {
+				load()
+				dirty = true
+			}
+		case err := <-errorChan:
+			checkError(errorMessage, err)
+		case <-ticker.C:
+			if reloading {
+				load()
+				dirty = true
+			}
+			// On a terminal the list is rendered again until the highlights
+			// expired, elsewhere only changes are printed. Nothing is shown
+			// before the routes were loaded, unless something failed.
+			if (!dirty && !(terminal && highlighted)) || (!loaded && status == "") {
+				continue
+			}
+			rows := watch.Rows()
+			highlighted = renderWatch(rows, snapshot.routerGroups, status, terminal, color)
+			dirty = false
+		}
+	}
+}
+
+// watchSnapshot holds the routes and TCP route mappings a RouteWatch starts
+// from, and the router group names to show the mappings with.
+type watchSnapshot struct {
+	routes       []models.Route
+	mappings     []models.TcpRouteMapping
+	routerGroups map[string]string
+}
+
+func loadWatchSnapshot(client routing_api.Client, watchHttp, watchTcp bool) (watchSnapshot, error) {
+	var snapshot watchSnapshot
+	var err error
+
+	if watchHttp {
+		snapshot.routes, err = commands.List(client)
+		if err != nil {
+			return snapshot, err
+		}
+	}
+
+	if watchTcp {
+		snapshot.mappings, err = commands.ListTcp(client, nil)
+		if err != nil {
+			return snapshot, err
+		}
+
+		groups, err := commands.ListRouterGroups(client)
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.routerGroups = make(map[string]string, len(groups))
+		for _, group := range groups {
+			snapshot.routerGroups[group.Guid] = group.Name
+		}
+	}
+
+	return snapshot, nil
+}
+
+// newWatchStream returns an event stream that retries forever, reporting
+// failures on statusChan and every successful subscription on subscribed.
+func newWatchStream(client routing_api.Client, refresher *commands.TokenRefresher, kind string, statusChan chan string, subscribed chan struct{}) commands.EventStream {
+	return commands.EventStream{
+		Client:     client,
+		Refresher:  refresher,
+		Subscribed: func() { subscribed <- struct{}{} },
+		Forever:    true,
+		Backoff:    time.Second,
+		Reconnecting: func(attempt int, delay time.Duration, err error) {
+			statusChan <- fmt.Sprintf("%s event stream failed: %s, reconnecting in %s (attempt %d)", kind, err, delay, attempt)
+		},
+		Clock: clock.NewClock(),
+	}
+}
+
+// renderWatch prints the rows, replacing the previous list on a terminal,
+// and reports whether any of them is highlighted.
+func renderWatch(rows []commands.WatchRow, routerGroups map[string]string, status string, terminal, color bool) bool {
+	var table bytes.Buffer
+	err := output.Write(&table, output.Table, watchRows{rows: rows, routerGroups: routerGroups})
+	checkError("watching routes failed:", err)
+
+	routes, tcpRoutes, highlighted := 0, 0, false
+	for _, row := range rows {
+		if row.State != "" {
+			highlighted = true
+		}
+		if row.State == commands.RowRemoved {
+			continue
+		}
+		if row.Route != nil {
+			routes++
+		} else {
+			tcpRoutes++
+		}
+	}
+
+	var screen bytes.Buffer
+	if terminal {
+		screen.WriteString("\033[H\033[2J")
+	} else {
+		screen.WriteString("\n")
+	}
+	fmt.Fprintf(&screen, "%s: %d routes and %d tcp routes\n", time.Now().Format("15:04:05"), routes, tcpRoutes)
+	if status != "" {
+		fmt.Fprintln(&screen, status)
+	}
+	screen.WriteString("\n")
+
+	// The first line of the table is its header, the others follow the rows.
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	for i, line := range lines {
+		if color && i > 0 && i <= len(rows) {
+			if code := watchColor(rows[i-1].State); code != "" {
+				line = code + line + colorReset
+			}
+		}
+		screen.WriteString(line + "\n")
+	}
+
+	_, err = os.Stdout.Write(screen.Bytes())
+	checkError("watching routes failed:", err)
+	return highlighted
+}
+
+func watchMark(state string) string {
+	switch state {
+	case commands.RowAdded:
+		return "+"
+	case commands.RowUpdated:
+		return "~"
+	case commands.RowRemoved:
+		return "-"
+	}
+	return ""
+}
+
+func watchColor(state string) string {
+	switch state {
+	case commands.RowAdded:
+		return colorGreen
+	case commands.RowUpdated:
+		return colorYellow
+	case commands.RowRemoved:
+		return colorRed
+	}
+	return ""
+}
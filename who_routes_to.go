@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"code.cloudfoundry.org/routing-api-cli/commands"
+	"github.com/urfave/cli"
+)
+
+var whoRoutesToCommand = cli.Command{
+	Name:      "who-routes-to",
+	Usage:     "Lists the HTTP routes and TCP routes to a backend IP, IP and port, or CIDR",
+	ArgsUsage: "<ip>[:<port>] | <cidr>",
+	Action:    whoRoutesTo,
+	Flags:     append(flags, outputFlag),
+}
+
+type backendRoutes []commands.BackendRoute
+
+func (routes backendRoutes) Header() []string {
+	return []string{"type", "backend", "route", "log_guid", "router_group", "port"}
+}
+
+func (routes backendRoutes) Rows() [][]string {
+	rows := make([][]string, 0, len(routes))
+	for _, route := range routes {
+		port := ""
+		if route.Port != 0 {
+			port = strconv.Itoa(route.Port)
+		}
+		rows = append(rows, []string{
+			route.Type,
+			route.Backend,
+			route.Route,
+			route.LogGuid,
+			route.RouterGroup,
+			port,
+		})
+	}
+	return rows
+}
+
+func whoRoutesTo(c *cli.Context) {
+	errorMessage := "looking up routes failed:"
+	issues := checkFlags(c)
+	issues = append(issues, checkArguments(c, "who-routes-to")...)
+	issues = append(issues, checkOutputFormat(c)...)
+
+	query, err := commands.ParseBackendQuery(c.Args().First())
+	if len(c.Args()) == 1 && err != nil {
+		issues = append(issues, fmt.Sprintf("Invalid backend: %s: %s", c.Args().First(), err))
+	}
+
+	if len(issues) > 0 {
+		printHelpForCommand(c, issues, "who-routes-to")
+	}
+
+	client, err := newRoutingApiClient(c)
+	checkError(errorMessage, err)
+
+	routes, err := commands.WhoRoutesTo(client, query)
+	checkError(errorMessage, err)
+
+	printRecords(c, errorMessage, backendRoutes(routes))
+}